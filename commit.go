@@ -0,0 +1,17 @@
+package keccak
+
+// Commit returns a hiding, binding commitment to message: the Keccak-256
+// hash of nonce and message, each framed with its length as in Sum256Framed,
+// so no other (nonce, message) split of the same bytes opens it. nonce must
+// be secret and random, at least 32 bytes from crypto/rand, until the
+// commitment is opened; a guessable nonce lets anyone test candidate
+// messages against it.
+func Commit(message, nonce []byte) [32]byte {
+	return Sum256Framed(nonce, message)
+}
+
+// VerifyCommit reports whether message and nonce open commitment, comparing
+// in constant time.
+func VerifyCommit(commitment [32]byte, message, nonce []byte) bool {
+	return Equal(Commit(message, nonce), commitment)
+}
@@ -0,0 +1,29 @@
+package keccak
+
+import "testing"
+
+func TestCommit(t *testing.T) {
+	message := []byte("bid: 42 ETH")
+	nonce := []byte("0123456789abcdef0123456789abcdef")
+	c := Commit(message, nonce)
+
+	if !VerifyCommit(c, message, nonce) {
+		t.Fatal("commitment does not open with its message and nonce")
+	}
+	if VerifyCommit(c, []byte("bid: 43 ETH"), nonce) {
+		t.Error("commitment opens with a different message")
+	}
+	if VerifyCommit(c, message, []byte("0123456789abcdef0123456789abcdeF")) {
+		t.Error("commitment opens with a different nonce")
+	}
+	// Moving bytes between nonce and message changes the commitment.
+	if VerifyCommit(c, append(nonce[31:], message...), nonce[:31]) {
+		t.Error("commitment opens with the nonce/message boundary shifted")
+	}
+	if Commit(message, nonce) == Sum256(append(nonce, message...)) {
+		t.Error("Commit is the unframed hash of nonce || message")
+	}
+	if n := testing.AllocsPerRun(100, func() { VerifyCommit(c, message, nonce) }); n != 0 {
+		t.Errorf("VerifyCommit: got %v allocs, want 0", n)
+	}
+}
@@ -0,0 +1,87 @@
+package keccak
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+// The state encoding of golang.org/x/crypto/sha3's legacy Keccak hashes:
+//
+//	magic || rate || state [200]byte || n || direction
+//
+// where the pending input is already XORed into state, n is the absorb or
+// read position, and direction is 0 while absorbing and 1 while squeezing.
+const (
+	xcryptoMagic         = "sha\x0b"
+	xcryptoMarshaledSize = len(xcryptoMagic) + 1 + 200 + 1 + 1
+)
+
+// ErrInvalidState is the error for a marshaled hasher state that is
+// truncated, comes from another hash function, or is internally
+// inconsistent. Nothing is restored when it is returned.
+type ErrInvalidState struct {
+	Reason string
+}
+
+func (e *ErrInvalidState) Error() string { return "keccak: invalid hasher state: " + e.Reason }
+
+// MarshalBinaryCompat encodes the hasher's state in the format of the
+// encoding.BinaryMarshaler returned by x/crypto/sha3.NewLegacyKeccak256, so
+// a state persisted here can be resumed there. It never returns an error.
+func (h *Hasher) MarshalBinaryCompat() ([]byte, error) {
+	h.guard.enter()
+	defer h.guard.exit()
+	s := &h.sponge
+	b := make([]byte, 0, xcryptoMarshaledSize)
+	b = append(b, xcryptoMagic...)
+	b = append(b, rate)
+	b = append(b, s.state[:]...)
+	if s.squeezing {
+		return append(b, byte(s.readIdx), 1), nil
+	}
+	a := b[len(b)-200:]
+	subtle.XORBytes(a, a, s.buf[:s.absorbed])
+	return append(b, byte(s.absorbed), 0), nil
+}
+
+// UnmarshalBinaryCompat restores a state marshaled by the
+// encoding.BinaryMarshaler of x/crypto/sha3.NewLegacyKeccak256, so systems
+// that persisted x/crypto hashes can resume them here. It rejects states of
+// any other x/crypto hash, including SHA-3 and Keccak-512, and any malformed
+// input, with an *ErrInvalidState.
+func (h *Hasher) UnmarshalBinaryCompat(b []byte) error {
+	if len(b) != xcryptoMarshaledSize {
+		return &ErrInvalidState{fmt.Sprintf("%d bytes, want %d", len(b), xcryptoMarshaledSize)}
+	}
+	if string(b[:len(xcryptoMagic)]) != xcryptoMagic {
+		return &ErrInvalidState{"not an x/crypto Keccak state"}
+	}
+	b = b[len(xcryptoMagic):]
+	if b[0] != rate {
+		return &ErrInvalidState{fmt.Sprintf("rate %d, want %d for Keccak-256", b[0], rate)}
+	}
+	n, direction := int(b[201]), b[202]
+	if direction > 1 {
+		return &ErrInvalidState{fmt.Sprintf("sponge direction %d", direction)}
+	}
+	if n > rate || (direction == 0 && n == rate) {
+		return &ErrInvalidState{fmt.Sprintf("position %d out of range", n)}
+	}
+
+	h.guard.enter()
+	defer h.guard.exit()
+	// x/crypto has already XORed the pending input into the state, so it
+	// carries over with an empty buffer: the zero bytes below absorbed
+	// leave it unchanged when the block is completed. permuted must be set
+	// for Sum256 to use the state at all.
+	h.sponge = sponge{permuted: true}
+	s := &h.sponge
+	copy(s.state[:], b[1:201])
+	if direction == 1 {
+		s.squeezing = true
+		s.readIdx = n
+	} else {
+		s.absorbed = n
+	}
+	return nil
+}
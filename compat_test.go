@@ -0,0 +1,192 @@
+package keccak
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"io"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestUnmarshalBinaryCompat(t *testing.T) {
+	data := make([]byte, 3*rate+20)
+	for i := range data {
+		data[i] = byte(i*37 + 11)
+	}
+	want := Sum256(data)
+	for _, k := range []int{0, 1, rate - 1, rate, rate + 1, 2*rate + 5, len(data)} {
+		ref := sha3.NewLegacyKeccak256()
+		ref.Write(data[:k])
+		state, err := ref.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var h Hasher
+		h.Write([]byte("overwritten"))
+		if err := h.UnmarshalBinaryCompat(state); err != nil {
+			t.Fatalf("k=%d: %v", k, err)
+		}
+		if h.Pending() != k%rate {
+			t.Errorf("k=%d: Pending = %d, want %d", k, h.Pending(), k%rate)
+		}
+		h.Write(data[k:])
+		if got := h.Sum256(); got != want {
+			t.Errorf("k=%d: resumed x/crypto state hashes to %x, want %x", k, got, want)
+		}
+		// Splitting the rest exercises completing the imported block through
+		// the carry buffer.
+		h.UnmarshalBinaryCompat(state)
+		for _, c := range data[k:] {
+			h.Write([]byte{c})
+		}
+		if got := h.Sum256(); got != want {
+			t.Errorf("k=%d: byte-by-byte resume hashes to %x, want %x", k, got, want)
+		}
+	}
+}
+
+func TestUnmarshalBinaryCompatSqueezing(t *testing.T) {
+	for _, read := range []int{1, 32, rate - 1, rate, rate + 3} {
+		ref := sha3.NewLegacyKeccak256()
+		ref.Write([]byte("xof"))
+		ref.(io.Reader).Read(make([]byte, read))
+		state, err := ref.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var h Hasher
+		if err := h.UnmarshalBinaryCompat(state); err != nil {
+			t.Fatalf("read=%d: %v", read, err)
+		}
+		want := make([]byte, 2*rate)
+		ref.(io.Reader).Read(want)
+		got := make([]byte, len(want))
+		h.Read(got)
+		if !bytes.Equal(got, want) {
+			t.Errorf("read=%d: resumed squeeze\ngot:  %x\nwant: %x", read, got, want)
+		}
+	}
+}
+
+func TestMarshalBinaryCompat(t *testing.T) {
+	data := make([]byte, 3*rate+20)
+	for i := range data {
+		data[i] = byte(i*37 + 11)
+	}
+	for _, k := range []int{0, 1, rate - 1, rate, 2*rate + 5, len(data)} {
+		var h Hasher
+		h.Write(data[:k])
+		state, err := h.MarshalBinaryCompat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref := sha3.NewLegacyKeccak256()
+		if err := ref.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+			t.Fatalf("k=%d: x/crypto rejected the state: %v", k, err)
+		}
+		ref.Write(data[k:])
+		if got, want := ref.Sum(nil), Sum256(data); !bytes.Equal(got, want[:]) {
+			t.Errorf("k=%d: x/crypto resumed to %x, want %x", k, got, want)
+		}
+
+		// Marshaling does not disturb the hasher, and round-trips here.
+		var h2 Hasher
+		if err := h2.UnmarshalBinaryCompat(state); err != nil {
+			t.Fatal(err)
+		}
+		if h.Sum256() != h2.Sum256() {
+			t.Errorf("k=%d: round trip changed the digest", k)
+		}
+	}
+
+	var h Hasher
+	h.Write([]byte("xof"))
+	h.Read(make([]byte, rate+3))
+	state, _ := h.MarshalBinaryCompat()
+	ref := sha3.NewLegacyKeccak256()
+	if err := ref.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	want := make([]byte, 50)
+	ref.(io.Reader).Read(want)
+	got := make([]byte, 50)
+	h.Read(got)
+	if !bytes.Equal(got, want) {
+		t.Errorf("squeezing state resumed in x/crypto\ngot:  %x\nwant: %x", got, want)
+	}
+}
+
+func TestUnmarshalBinaryCompatErrors(t *testing.T) {
+	valid, _ := sha3.NewLegacyKeccak256().(encoding.BinaryMarshaler).MarshalBinary()
+	other := func(h any) []byte {
+		b, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+		return b
+	}
+	tamper := func(i int, v byte) []byte {
+		b := bytes.Clone(valid)
+		b[i] = v
+		return b
+	}
+	for name, b := range map[string][]byte{
+		"empty":       nil,
+		"short":       valid[:len(valid)-1],
+		"SHA3-256":    other(sha3.New256()),
+		"Keccak-512":  other(sha3.NewLegacyKeccak512()),
+		"n past rate": tamper(len(valid)-2, rate+1),
+		"full absorb": tamper(len(valid)-2, rate),
+		"direction 2": tamper(len(valid)-1, 2),
+		"wrong rate":  tamper(len(xcryptoMagic), 72),
+		"wrong magic": tamper(0, 'x'),
+	} {
+		var h Hasher
+		h.Write([]byte("kept"))
+		want := h.Sum256()
+		var invalid *ErrInvalidState
+		if err := h.UnmarshalBinaryCompat(b); !errors.As(err, &invalid) {
+			t.Errorf("%s: got %v, want an *ErrInvalidState", name, err)
+		}
+		if h.Sum256() != want {
+			t.Errorf("%s: rejected state modified the hasher", name)
+		}
+	}
+
+	// Every truncation is rejected cleanly.
+	for n := range valid {
+		var h Hasher
+		var invalid *ErrInvalidState
+		if err := h.UnmarshalBinaryCompat(valid[:n]); !errors.As(err, &invalid) {
+			t.Fatalf("truncated to %d bytes: got %v, want an *ErrInvalidState", n, err)
+		}
+	}
+}
+
+func FuzzUnmarshalBinaryCompat(f *testing.F) {
+	valid, _ := sha3.NewLegacyKeccak256().(encoding.BinaryMarshaler).MarshalBinary()
+	f.Add(valid)
+	f.Add(valid[:10])
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var h Hasher
+		if err := h.UnmarshalBinaryCompat(b); err != nil {
+			var invalid *ErrInvalidState
+			if !errors.As(err, &invalid) {
+				t.Fatalf("got %T, want an *ErrInvalidState", err)
+			}
+			return
+		}
+		// An accepted state agrees with x/crypto from here on.
+		ref := sha3.NewLegacyKeccak256()
+		if err := ref.(encoding.BinaryUnmarshaler).UnmarshalBinary(b); err != nil {
+			t.Fatalf("accepted a state x/crypto rejects: %v", err)
+		}
+		got := make([]byte, 40)
+		want := make([]byte, 40)
+		h.Read(got)
+		ref.(io.Reader).Read(want)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("got %x, want %x", got, want)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package keccak
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Hash is a 32-byte Keccak-256 digest that renders and parses as 0x-prefixed hex.
+type Hash [32]byte
+
+// Sum256Hash computes the Keccak-256 hash of data as a Hash.
+func Sum256Hash(data []byte) Hash {
+	return Hash(Sum256(data))
+}
+
+// Sum256Hex computes the Keccak-256 hash of data and returns it as 64
+// lowercase hex digits without a 0x prefix. It does not allocate.
+func Sum256Hex(data []byte) [64]byte {
+	d := Sum256(data)
+	var out [64]byte
+	encodeHex(out[:], d[:])
+	return out
+}
+
+const hexDigits = "0123456789abcdef"
+
+// encodeHex writes the lowercase hex encoding of src into dst,
+// which must hold 2*len(src) bytes.
+func encodeHex(dst, src []byte) {
+	_ = dst[2*len(src)-1]
+	for i, b := range src {
+		dst[2*i] = hexDigits[b>>4]
+		dst[2*i+1] = hexDigits[b&0x0f]
+	}
+}
+
+// AppendHex appends the 64 lowercase hex digits of h, without a 0x prefix,
+// to dst and returns the extended slice.
+func (h Hash) AppendHex(dst []byte) []byte {
+	n := len(dst)
+	dst = append(dst, make([]byte, 2*len(h))...)
+	encodeHex(dst[n:], h[:])
+	return dst
+}
+
+// Hex returns the 0x-prefixed lowercase hex encoding of h.
+func (h Hash) Hex() string {
+	var b [2 + 2*len(h)]byte
+	b[0], b[1] = '0', 'x'
+	encodeHex(b[2:], h[:])
+	return string(b[:])
+}
+
+// String returns the 0x-prefixed lowercase hex encoding of h.
+func (h Hash) String() string {
+	return h.Hex()
+}
+
+// Format implements fmt.Formatter so that a Hash never prints as a list of
+// byte values. %v and %s print String's 0x-prefixed hex and %q quotes it;
+// %x and %X print bare hex digits, prefixed by 0x or 0X with the # flag.
+func (h Hash) Format(s fmt.State, verb rune) {
+	var b [2 + 2 + 2*len(h)]byte
+	switch verb {
+	case 'v', 's':
+		s.Write([]byte(h.Hex()))
+	case 'q':
+		out := append(b[:0], '"')
+		out = append(out, h.Hex()...)
+		s.Write(append(out, '"'))
+	case 'x', 'X':
+		out := b[:0]
+		if s.Flag('#') {
+			out = append(out, '0', byte(verb))
+		}
+		out = h.AppendHex(out)
+		if verb == 'X' {
+			for i := len(out) - 2*len(h); i < len(out); i++ {
+				if out[i] >= 'a' {
+					out[i] -= 'a' - 'A'
+				}
+			}
+		}
+		s.Write(out)
+	default:
+		fmt.Fprintf(s, "%%!%c(keccak.Hash=%s)", verb, h.Hex())
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.Hex()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts exactly 64 hex
+// digits, with or without a 0x prefix.
+func (h *Hash) UnmarshalText(text []byte) error {
+	if len(text) >= 2 && text[0] == '0' && (text[1] == 'x' || text[1] == 'X') {
+		text = text[2:]
+	}
+	if len(text) != 2*len(h) {
+		return fmt.Errorf("keccak: hash hex has %d digits, want %d", len(text), 2*len(h))
+	}
+	var out Hash
+	if _, err := hex.Decode(out[:], text); err != nil {
+		return fmt.Errorf("keccak: invalid hash hex: %w", err)
+	}
+	*h = out
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, encoding h as a 0x-prefixed hex string.
+func (h Hash) MarshalJSON() ([]byte, error) {
+	b := make([]byte, 0, 2+2+2*len(h))
+	b = append(b, `"0x`...)
+	b = h.AppendHex(b)
+	return append(b, '"'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts a JSON string holding
+// 64 hex digits, with or without a 0x prefix.
+func (h *Hash) UnmarshalJSON(data []byte) error {
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return errors.New("keccak: hash JSON value is not a string")
+	}
+	return h.UnmarshalText(data[1 : len(data)-1])
+}
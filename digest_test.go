@@ -0,0 +1,146 @@
+package keccak
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHashString(t *testing.T) {
+	h := Sum256Hash([]byte("hello"))
+	const want = "0x1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8"
+	if h.String() != want || h.Hex() != want {
+		t.Fatalf("String/Hex = %s/%s, want %s", h.String(), h.Hex(), want)
+	}
+	if Hash(Sum256([]byte("hello"))) != h {
+		t.Fatal("Sum256Hash disagrees with Sum256")
+	}
+}
+
+func TestHashFormat(t *testing.T) {
+	h := Sum256Hash([]byte("hello"))
+	const digits = "1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8"
+	upper := strings.ToUpper(digits)
+	for _, tc := range []struct {
+		format string
+		want   string
+	}{
+		{"%v", "0x" + digits},
+		{"%s", "0x" + digits},
+		{"%q", `"0x` + digits + `"`},
+		{"%x", digits},
+		{"%#x", "0x" + digits},
+		{"%X", upper},
+		{"%#X", "0X" + upper},
+		{"%d", "%!d(keccak.Hash=0x" + digits + ")"},
+	} {
+		if got := fmt.Sprintf(tc.format, h); got != tc.want {
+			t.Errorf("Sprintf(%q) = %s, want %s", tc.format, got, tc.want)
+		}
+	}
+	if got := fmt.Sprint([]Hash{h}); got != "[0x"+digits+"]" {
+		t.Errorf("Sprint([]Hash) = %s", got)
+	}
+}
+
+func TestSum256Hex(t *testing.T) {
+	for _, size := range []int{0, 1, 32, rate, 1000} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		d := Sum256(data)
+		want := hex.EncodeToString(d[:])
+		if got := Sum256Hex(data); string(got[:]) != want {
+			t.Errorf("Sum256Hex len=%d = %s, want %s", size, got, want)
+		}
+		prefix := []byte("digest=")
+		if got := Hash(d).AppendHex(prefix); string(got) != "digest="+want {
+			t.Errorf("AppendHex len=%d = %s, want digest=%s", size, got, want)
+		}
+	}
+}
+
+func TestHashTextRoundTrip(t *testing.T) {
+	h := Sum256Hash([]byte("round trip"))
+	text, err := h.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Hash
+	if err := got.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if got != h {
+		t.Fatalf("text round trip = %s, want %s", got, h)
+	}
+
+	// Without prefix and with uppercase digits.
+	var bare Hash
+	if err := bare.UnmarshalText([]byte("1C8AFF950685C2ED4BC3174F3472287B56D9517B9C948127319A09A7A36DEAC8")); err != nil {
+		t.Fatal(err)
+	}
+	if bare != Sum256Hash([]byte("hello")) {
+		t.Fatalf("unprefixed parse = %s", bare)
+	}
+}
+
+func TestHashJSONRoundTrip(t *testing.T) {
+	type doc struct {
+		Root Hash   `json:"root"`
+		List []Hash `json:"list"`
+	}
+	in := doc{Root: Sum256Hash(nil), List: []Hash{Sum256Hash([]byte("a")), {}}}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out doc
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Root != in.Root || len(out.List) != 2 || out.List[0] != in.List[0] || out.List[1] != in.List[1] {
+		t.Fatalf("JSON round trip mismatch: %s", b)
+	}
+
+	var h Hash
+	if err := json.Unmarshal([]byte(`"c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"`), &h); err != nil {
+		t.Fatal(err)
+	}
+	if h != Sum256Hash(nil) {
+		t.Fatalf("unprefixed JSON parse = %s", h)
+	}
+}
+
+func TestHashUnmarshalErrors(t *testing.T) {
+	for _, in := range []string{"", "0x", "0x1234", strings.Repeat("z", 64), "0x" + strings.Repeat("0", 66)} {
+		var h Hash
+		if err := h.UnmarshalText([]byte(in)); err == nil {
+			t.Errorf("UnmarshalText(%q) succeeded", in)
+		}
+	}
+	var h Hash
+	if err := json.Unmarshal([]byte(`12`), &h); err == nil {
+		t.Error("UnmarshalJSON accepted a number")
+	}
+}
+
+func BenchmarkSum256Hex(b *testing.B) {
+	data := make([]byte, 32)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		Sum256Hex(data)
+	}
+}
+
+func BenchmarkHashAppendHex(b *testing.B) {
+	h := Sum256Hash(nil)
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	for b.Loop() {
+		buf = h.AppendHex(buf[:0])
+	}
+}
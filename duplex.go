@@ -0,0 +1,64 @@
+package keccak
+
+import "encoding/binary"
+
+// Duplex is the duplex construction of Bertoni et al., "Duplexing the
+// sponge" (SAC 2011), over Keccak-f[1600]. Each Duplexing call absorbs one
+// padded block and squeezes output from the same permutation call, which is
+// the building block of sponge-based authenticated encryption.
+//
+// The rate r (bytes) and capacity c = 200 - r trade speed for security:
+// generic attacks cost about 2^(4c) work, so r = 136 (c = 64 bytes) gives a
+// 256-bit security level, as in SHA3-256, and r = 168 gives 128 bits.
+type Duplex struct {
+	state [200]byte
+	rate  int
+}
+
+// NewDuplex returns a duplex object with the given rate in bytes.
+// Panics if rate is outside [1, 199].
+func NewDuplex(rate int) *Duplex {
+	if rate < 1 || rate >= 200 {
+		panic("keccak: Duplex rate out of range")
+	}
+	return &Duplex{rate: rate}
+}
+
+// Rate returns the duplex rate in bytes.
+func (d *Duplex) Rate() int { return d.rate }
+
+// StateLanes returns the Keccak state as 25 lanes, lane x+5y at index
+// x+5y. Each lane is the little-endian reading of its 8 state bytes, as in
+// FIPS 202, whatever the byte order of the host.
+func (d *Duplex) StateLanes() [25]uint64 {
+	var lanes [25]uint64
+	for i := range lanes {
+		lanes[i] = binary.LittleEndian.Uint64(d.state[i*8:])
+	}
+	return lanes
+}
+
+// SetStateLanes replaces the Keccak state with lanes, laid out as in
+// StateLanes.
+func (d *Duplex) SetStateLanes(lanes [25]uint64) {
+	for i, v := range lanes {
+		binary.LittleEndian.PutUint64(d.state[i*8:], v)
+	}
+}
+
+// Duplexing absorbs in followed by the multi-rate padding pad10*1, applies
+// Keccak-f[1600] once, and returns the first outLen bytes of the new state.
+// Panics if len(in) >= Rate() or outLen > Rate().
+func (d *Duplex) Duplexing(in []byte, outLen int) []byte {
+	if len(in) >= d.rate {
+		panic("keccak: Duplex input must be shorter than the rate")
+	}
+	if outLen < 0 || outLen > d.rate {
+		panic("keccak: Duplex output longer than the rate")
+	}
+	xorIn(&d.state, in)
+	d.state[len(in)] ^= 0x01
+	d.state[d.rate-1] ^= 0x80
+	keccakF1600(&d.state)
+	return append([]byte(nil), d.state[:outLen]...)
+}
@@ -0,0 +1,101 @@
+package keccak
+
+import (
+	"math/bits"
+	"testing"
+)
+
+// rhoOffsets are the rho rotation amounts for lane x+5y.
+var rhoOffsets = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// refKeccakF1600 is a straightforward, table-driven Keccak-f[1600] on lanes,
+// independent of the unrolled implementations.
+func refKeccakF1600(a *[25]uint64) {
+	for _, rc := range roundConstants {
+		var c [5]uint64
+		for x := range 5 {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := range 5 {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := range 5 {
+				a[x+5*y] ^= d
+			}
+		}
+		var b [25]uint64
+		for x := range 5 {
+			for y := range 5 {
+				b[y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y], rhoOffsets[x+5*y])
+			}
+		}
+		for x := range 5 {
+			for y := range 5 {
+				a[x+5*y] = b[x+5*y] ^ (^b[(x+1)%5+5*y] & b[(x+2)%5+5*y])
+			}
+		}
+		a[0] ^= rc
+	}
+}
+
+// refSum256 computes Keccak-256 byte by byte: byte i of the state is bits
+// 8*(i%8) and up of lane i/8, assembled with shifts only. It never
+// reinterprets memory, so it is correct on either byte order and catches
+// any endianness mistake in the lane conversions of the real sponge.
+func refSum256(data []byte) [32]byte {
+	var a [25]uint64
+	xorByte := func(i int, b byte) { a[i/8] ^= uint64(b) << (8 * (i % 8)) }
+	for len(data) >= rate {
+		for i := range rate {
+			xorByte(i, data[i])
+		}
+		refKeccakF1600(&a)
+		data = data[rate:]
+	}
+	for i, b := range data {
+		xorByte(i, b)
+	}
+	xorByte(len(data), 0x01)
+	xorByte(rate-1, 0x80)
+	refKeccakF1600(&a)
+	var out [32]byte
+	for i := range out {
+		out[i] = byte(a[i/8] >> (8 * (i % 8)))
+	}
+	return out
+}
+
+func TestSum256MatchesByteOrderReference(t *testing.T) {
+	check := func(t *testing.T) {
+		for _, kat := range keccakKATs {
+			if got, want := Sum256([]byte(kat.input)), refSum256([]byte(kat.input)); got != want {
+				t.Errorf("%s: Sum256 = %x, reference %x", kat.name, got, want)
+			}
+		}
+		for n := range 3*rate + 2 {
+			data := make([]byte, n)
+			for i := range data {
+				data[i] = byte(i*29 + n)
+			}
+			want := refSum256(data)
+			if got := Sum256(data); got != want {
+				t.Fatalf("len=%d: Sum256 = %x, reference %x", n, got, want)
+			}
+			var h Hasher
+			h.Write(data[:n/3])
+			h.Write(data[n/3:])
+			var out [32]byte
+			h.Read(out[:])
+			if out != want {
+				t.Fatalf("len=%d: Hasher.Read = %x, reference %x", n, out, want)
+			}
+		}
+	}
+	t.Run("native", check)
+	t.Run("generic", func(t *testing.T) { withGeneric(func() { check(t) }) })
+}
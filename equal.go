@@ -0,0 +1,15 @@
+package keccak
+
+import "crypto/subtle"
+
+// Equal reports whether a and b are equal, in constant time.
+// Use it instead of == when comparing MACs or other secret-derived digests.
+func Equal(a, b [32]byte) bool {
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
+
+// EqualSlice reports whether a and b are equal, in time that depends only on
+// their lengths. Slices of different lengths are never equal.
+func EqualSlice(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
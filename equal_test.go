@@ -0,0 +1,28 @@
+package keccak
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	a := Sum256([]byte("hello"))
+
+	b := a
+	if !Equal(a, b) || !EqualSlice(a[:], b[:]) {
+		t.Fatal("identical digests compared unequal")
+	}
+
+	b = a
+	b[0] ^= 1
+	if Equal(a, b) || EqualSlice(a[:], b[:]) {
+		t.Fatal("digests differing in the first byte compared equal")
+	}
+
+	b = a
+	b[31] ^= 0x80
+	if Equal(a, b) || EqualSlice(a[:], b[:]) {
+		t.Fatal("digests differing in the last byte compared equal")
+	}
+
+	if EqualSlice(a[:], a[:31]) {
+		t.Fatal("slices of different length compared equal")
+	}
+}
@@ -0,0 +1,69 @@
+package keccak
+
+import (
+	"encoding/binary"
+	"math/big"
+	"strconv"
+)
+
+const ethMessagePrefix = "\x19Ethereum Signed Message:\n"
+
+// HashEthereumMessage returns the EIP-191 personal_sign hash of msg:
+//
+//	keccak256("\x19Ethereum Signed Message:\n" || len(msg) || msg)
+//
+// where len(msg) is the ASCII decimal byte length. The message is absorbed
+// after the prefix without building the concatenation.
+func HashEthereumMessage(msg []byte) [32]byte {
+	var prefix [len(ethMessagePrefix) + 20]byte
+	p := append(prefix[:0], ethMessagePrefix...)
+	p = strconv.AppendInt(p, int64(len(msg)), 10)
+
+	var h Hasher
+	h.Write(p)
+	h.Write(msg)
+	return h.Sum256()
+}
+
+// StorageSlot returns keccak256(key || slot), the storage slot of a Solidity
+// mapping value whose key is a 32-byte word (a uint256, bytes32, or an
+// address or smaller integer left-padded to 32 bytes) and whose mapping sits
+// at slot. The 64-byte input takes a single permutation.
+func StorageSlot(key, slot [32]byte) [32]byte {
+	return HashPair(key, slot)
+}
+
+// MappingSlot returns keccak256(key || baseSlot), the storage slot of a
+// Solidity mapping value whose key is a string or bytes, which Solidity
+// hashes unpadded. It does not allocate.
+func MappingSlot(key []byte, baseSlot [32]byte) [32]byte {
+	var s sponge
+	s.Write(key)
+	s.Write(baseSlot[:])
+	return s.Sum256()
+}
+
+// Sum256Word returns the Keccak-256 hash of the 256-bit integer whose 64-bit
+// limbs, least significant first, are w, encoded as the EVM does: 32 bytes,
+// big-endian, so w[3] supplies the first 8 bytes hashed. This is the layout
+// of github.com/holiman/uint256.Int; note that it is the reverse of the
+// little-endian lanes the sponge itself works in. It does not allocate.
+func Sum256Word(w [4]uint64) [32]byte {
+	var b [32]byte
+	for i, limb := range w {
+		binary.BigEndian.PutUint64(b[24-8*i:], limb)
+	}
+	return Sum256Of32(b)
+}
+
+// Sum256BigInt returns the Keccak-256 hash of x left-padded to 32 bytes
+// big-endian, the EVM encoding of a uint256. Panics if x is negative or
+// longer than 256 bits.
+func Sum256BigInt(x *big.Int) [32]byte {
+	if x.Sign() < 0 || x.BitLen() > 256 {
+		panic("keccak: Sum256BigInt of a value outside uint256")
+	}
+	var b [32]byte
+	x.FillBytes(b[:])
+	return Sum256Of32(b)
+}
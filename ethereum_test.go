@@ -0,0 +1,147 @@
+package keccak
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+func TestHashEthereumMessage(t *testing.T) {
+	// Vectors from ethers.js hashMessage.
+	tests := []struct {
+		msg  []byte
+		want string
+	}{
+		{[]byte("Hello World"), "a1de988600a42c4b4ab089b619297c17d53cffae5d5120d82d8a92d0bb3b78f2"},
+		{[]byte{0x42, 0x43}, "0d3abc18ec299cf9b42ba439ac6f7e3e6ec9f5c048943704e30fc2d9c7981438"},
+	}
+	for _, tt := range tests {
+		got := HashEthereumMessage(tt.msg)
+		if hex.EncodeToString(got[:]) != tt.want {
+			t.Errorf("HashEthereumMessage(%q) = %x, want %s", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestHashEthereumMessageLengths(t *testing.T) {
+	// Multi-digit lengths and messages spanning several blocks.
+	for _, n := range []int{0, 9, 10, 99, 100, rate, 1000, 12345} {
+		msg := make([]byte, n)
+		for i := range msg {
+			msg[i] = byte(i)
+		}
+		full := append([]byte(ethMessagePrefix+strconv.Itoa(n)), msg...)
+		if got, want := HashEthereumMessage(msg), Sum256(full); got != want {
+			t.Errorf("len=%d: got %x, want %x", n, got, want)
+		}
+	}
+}
+
+func TestStorageSlot(t *testing.T) {
+	word := func(s string) (w [32]byte) {
+		b, _ := hex.DecodeString(s)
+		copy(w[32-len(b):], b)
+		return w
+	}
+	// keccak256(abi.encode(key, slot)), as go-ethereum's
+	// crypto.Keccak256Hash(key, slot) computes it.
+	tests := []struct {
+		key, slot [32]byte
+		want      string
+	}{
+		{word(""), word(""), "ad3228b676f7d3cd4284a5443f17f1962b36e491b30a40b2405849e597ba5fb5"},
+		{word("01"), word("00"), "ada5013122d395ba3c54772283fb069b10426056ef8ca54750cb9bb552a59e7d"},
+		{word("f39fd6e51aad88f6f4ce6ab8827279cfffb92266"), word("02"), "bc40fbf4394cd00f78fae9763b0c2c71b21ea442c42fdadc5b720537240ebac1"},
+	}
+	for _, tt := range tests {
+		got := StorageSlot(tt.key, tt.slot)
+		if hex.EncodeToString(got[:]) != tt.want {
+			t.Errorf("StorageSlot(%x, %x) = %x, want %s", tt.key, tt.slot, got, tt.want)
+		}
+	}
+}
+
+func TestMappingSlot(t *testing.T) {
+	var base [32]byte
+	base[31] = 1
+	got := MappingSlot([]byte("hello"), base)
+	if want := "8404bb4d805e9ca2bd5dd5c43a107e935c8ec393caa7851b353b3192cd5379ae"; hex.EncodeToString(got[:]) != want {
+		t.Errorf("MappingSlot(%q) = %x, want %s", "hello", got, want)
+	}
+	// Keys long enough to push the slot across a block boundary.
+	for _, n := range []int{0, 32, rate - 32, rate - 31, rate, 1000} {
+		key := make([]byte, n)
+		for i := range key {
+			key[i] = byte(i)
+		}
+		if got, want := MappingSlot(key, base), Sum256(append(key, base[:]...)); got != want {
+			t.Errorf("len=%d: got %x, want %x", n, got, want)
+		}
+	}
+}
+
+func TestStorageSlotNoAllocs(t *testing.T) {
+	var key, slot [32]byte
+	data := make([]byte, 100)
+	if n := testing.AllocsPerRun(100, func() {
+		StorageSlot(key, slot)
+		MappingSlot(data, slot)
+	}); n != 0 {
+		t.Fatalf("got %v allocs, want 0", n)
+	}
+}
+
+func TestSum256Word(t *testing.T) {
+	// keccak256(abi.encode(uint256(x))) as evaluated by the EVM.
+	tests := []struct {
+		w    [4]uint64
+		x    string
+		want string
+	}{
+		{[4]uint64{}, "0", "290decd9548b62a8d60345a988386fc84ba6bc95484008f6362f93160ef3e563"},
+		{[4]uint64{1}, "1", "b10e2d527612073b26eecdfd717e6a320cf44b4afac2b0732d9fcbe2b7fa0cf6"},
+		{[4]uint64{2}, "2", "405787fa12a823e0f2b7631cc41b3ba8828b3321ca811111fa75cd3aa3bb5ace"},
+	}
+	for _, tt := range tests {
+		x, _ := new(big.Int).SetString(tt.x, 0)
+		if got := Sum256Word(tt.w); hex.EncodeToString(got[:]) != tt.want {
+			t.Errorf("Sum256Word(%v) = %x, want %s", tt.w, got, tt.want)
+		}
+		if got := Sum256BigInt(x); hex.EncodeToString(got[:]) != tt.want {
+			t.Errorf("Sum256BigInt(%s) = %x, want %s", x, got, tt.want)
+		}
+	}
+
+	// The limbs are ordered least significant first.
+	w := [4]uint64{0x0001020304050607, 0x08090a0b0c0d0e0f, 0x1011121314151617, 0x18191a1b1c1d1e1f}
+	x, _ := new(big.Int).SetString("0x18191a1b1c1d1e1f101112131415161708090a0b0c0d0e0f0001020304050607", 0)
+	be := x.FillBytes(make([]byte, 32))
+	want := Sum256(be)
+	if got := Sum256Word(w); got != want {
+		t.Errorf("Sum256Word(%x) = %x, want %x", w, got, want)
+	}
+	if got := Sum256BigInt(x); got != want {
+		t.Errorf("Sum256BigInt(%x) = %x, want %x", x, got, want)
+	}
+	maxWord := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	if got, want := Sum256BigInt(maxWord), Sum256Word([4]uint64{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}); got != want {
+		t.Errorf("Sum256BigInt(2^256-1) = %x, want %x", got, want)
+	}
+	if n := testing.AllocsPerRun(100, func() { Sum256Word(w) }); n != 0 {
+		t.Errorf("Sum256Word: got %v allocs, want 0", n)
+	}
+}
+
+func TestSum256BigIntOutOfRange(t *testing.T) {
+	for _, x := range []*big.Int{big.NewInt(-1), new(big.Int).Lsh(big.NewInt(1), 256)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Sum256BigInt(%s) did not panic", x)
+				}
+			}()
+			Sum256BigInt(x)
+		}()
+	}
+}
@@ -0,0 +1,510 @@
+//go:build ignore
+
+// gen_keccakf_avx.go generates keccakf_amd64_avx2.s and
+// keccakf_amd64_avx512.s — Keccak-f[1600] permutations that keep the whole
+// state in seven YMM registers.
+//
+// Layout, after OpenSSL's keccak1600-avx2: lane (x, y) is A[x+5y].
+//   - Y0 holds A(0,0) in all four lanes.
+//   - Y1 holds row 0, x = 1..4.
+//   - Y2 holds column 0, y = 1..4.
+//   - Yk, k = 3..6, holds the lanes (x, m·x mod 5) for x = 1..4, with
+//     m = 3, 2, 4, 1.
+//
+// With that split pi maps Y1 → Y2 → Y3 → Y4 → Y5 → Y6 → Y1, so it is one
+// VPERMQ per register instead of a transpose.
+//
+// Each register is kept in one of two lane orders:
+//   - x order: lane j holds x = j+1 (Y2: y = j+1). Theta and rho use it,
+//     since the column sums C[1..4] are then lanewise XORs of Y1 and Y3–Y6.
+//   - y order: lane j holds y = j+1 (Y1: x = j+1). Chi uses it, since a
+//     row's five lanes then share one lane of Y2–Y6, and each chi
+//     neighbour is gathered with blends instead of shuffles.
+//
+// Pi's VPERMQs land in y order; four more after chi return to x order.
+// The rounds run as a loop over the round constants, entered 12 constants
+// in for Keccak-p[1600, 12].
+//
+// The AVX-512 variant runs the same steps on the same YMM registers, with
+// the AVX-512VL instructions that shorten them: VPROLQ and VPROLVQ for
+// the rotations, and VPTERNLOGQ for three-input XORs and for chi.
+//
+// Usage: go run gen_keccakf_avx.go
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
+type pos struct{ x, y int }
+
+// mult gives the slope m of registers Y3–Y6: Yk holds (x, m·x mod 5).
+var mult = [7]int{3: 3, 4: 2, 5: 4, 6: 1}
+
+// xOrder returns the lane held by lane j of register k in x order.
+func xOrder(k, j int) pos {
+	switch k {
+	case 0:
+		return pos{0, 0}
+	case 1:
+		return pos{j + 1, 0}
+	case 2:
+		return pos{0, j + 1}
+	}
+	return pos{j + 1, mult[k] * (j + 1) % 5}
+}
+
+// yOrder returns the lane held by lane j of register k in y order.
+func yOrder(k, j int) pos {
+	if k < 3 {
+		return xOrder(k, j)
+	}
+	for x := 1; x < 5; x++ {
+		if mult[k]*x%5 == j+1 {
+			return pos{x, j + 1}
+		}
+	}
+	panic("no lane")
+}
+
+// locate returns the register and lane holding q in the given order. A
+// lane of Y0 is returned as want, since every lane of Y0 holds A(0,0).
+func locate(order func(k, j int) pos, q pos, want int) (int, int) {
+	if q == (pos{0, 0}) {
+		return 0, want
+	}
+	for k := 1; k < 7; k++ {
+		for j := 0; j < 4; j++ {
+			if order(k, j) == q {
+				return k, j
+			}
+		}
+	}
+	panic("no register")
+}
+
+func pi(q pos) pos { return pos{q.y, (2*q.x + 3*q.y) % 5} }
+
+// rho returns the left-rotation amount of every lane, from the definition
+// in FIPS 202, section 3.2.2.
+func rho() (r [5][5]int) {
+	x, y := 1, 0
+	for t := 0; t < 24; t++ {
+		r[x][y] = (t + 1) * (t + 2) / 2 % 64
+		x, y = y, (2*x+3*y)%5
+	}
+	return r
+}
+
+// permImm returns the VPERMQ immediate moving lane src[j] to lane j.
+func permImm(src [4]int) int {
+	return src[0] | src[1]<<2 | src[2]<<4 | src[3]<<6
+}
+
+func off(q pos) int { return 8 * (q.x + 5*q.y) }
+
+// ternImm returns the VPTERNLOGQ immediate computing f of the destination
+// a and the sources b and c, written VPTERNLOGQ $imm, c, b, a.
+func ternImm(f func(a, b, c uint8) uint8) uint8 {
+	return f(0xf0, 0xcc, 0xaa)
+}
+
+var xor3 = ternImm(func(a, b, c uint8) uint8 { return a ^ b ^ c })
+
+var (
+	p      func(string, ...any)
+	avx512 bool
+)
+
+func main() {
+	generate("keccakf_amd64_avx2.s", "keccakF1600AVX2", false)
+	generate("keccakf_amd64_avx512.s", "keccakF1600AVX512", true)
+}
+
+func generate(file, name string, wide bool) {
+	f, err := os.Create(file)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	p = func(format string, args ...any) { fmt.Fprintf(f, format+"\n", args...) }
+	avx512 = wide
+
+	p("// Code generated by gen_keccakf_avx.go. DO NOT EDIT.")
+	p("")
+	p("//go:build amd64 && !purego")
+	p("")
+	p("#include \"textflag.h\"")
+	p("")
+
+	// Per-lane rotations for rho, left and right, for Y1–Y6 in x order.
+	// VPROLVQ only needs the left ones.
+	r := rho()
+	sides := []string{"rhoLeft", "rhoRight"}
+	if avx512 {
+		sides = sides[:1]
+	}
+	for _, side := range sides {
+		for k := 1; k < 7; k++ {
+			for j := 0; j < 4; j++ {
+				q := xOrder(k, j)
+				n := r[q.x][q.y]
+				if side == "rhoRight" {
+					n = 64 - n
+				}
+				p("DATA %s<>+%d(SB)/8, $%d", side, 32*(k-1)+8*j, n)
+			}
+		}
+		p("GLOBL %s<>(SB), RODATA|NOPTR, $192", side)
+		p("")
+	}
+
+	// Single function: name(a *[200]byte, buf *byte, rounds int), with the
+	// same contract as keccakF1600BMI2.
+	p("// func %s(a *[200]byte, buf *byte, rounds int)", name)
+	p("TEXT ·%s(SB), NOSPLIT, $0-24", name)
+	p("\tMOVQ a+0(FP), DI")
+	p("\tMOVQ buf+8(FP), BX")
+	p("\tTESTQ BX, BX")
+	p("\tJZ load")
+	p("")
+	p("\t// XOR 136 bytes of buf into state.")
+	for o := 0; o < 128; o += 32 {
+		p("\tVMOVDQU %d(BX), Y0", o)
+		p("\tVPXOR %d(DI), Y0, Y0", o)
+		p("\tVMOVDQU Y0, %d(DI)", o)
+	}
+	p("\tMOVQ 128(BX), AX")
+	p("\tXORQ AX, 128(DI)")
+	p("")
+	p("load:")
+	emitLoad()
+	if avx512 {
+		for i, m := range chiMasks() {
+			p("\tMOVL $%#x, AX", m)
+			p("\tKMOVW AX, K%d", i+1)
+		}
+	}
+	p("")
+	p("\t// CX walks the round constants from round 24-rounds to DX.")
+	p("\tLEAQ ·roundConstants+192(SB), DX")
+	p("\tMOVQ rounds+16(FP), AX")
+	p("\tSHLQ $3, AX")
+	p("\tMOVQ DX, CX")
+	p("\tSUBQ AX, CX")
+	p("")
+	p("loop:")
+	emitTheta()
+	emitRhoPi()
+	emitChiIota()
+	p("\tADDQ $8, CX")
+	p("\tCMPQ CX, DX")
+	p("\tJNE loop")
+	p("")
+	emitStore()
+	p("\tVZEROUPPER")
+	p("\tRET")
+}
+
+// emitLoad loads the state into Y0–Y6 in x order.
+func emitLoad() {
+	p("\tVPBROADCASTQ 0(DI), Y0")
+	p("\tVMOVDQU 8(DI), Y1")
+	for k := 2; k < 7; k++ {
+		p("\tVMOVQ %d(DI), X%d", off(xOrder(k, 0)), k)
+		p("\tVPINSRQ $1, %d(DI), X%d, X%d", off(xOrder(k, 1)), k, k)
+		p("\tVMOVQ %d(DI), X15", off(xOrder(k, 2)))
+		p("\tVPINSRQ $1, %d(DI), X15, X15", off(xOrder(k, 3)))
+		p("\tVINSERTI128 $1, X15, Y%d, Y%d", k, k)
+	}
+}
+
+// emitStore stores Y0–Y6, in x order, back to the state.
+func emitStore() {
+	p("\tVMOVQ X0, 0(DI)")
+	p("\tVMOVDQU Y1, 8(DI)")
+	for k := 2; k < 7; k++ {
+		p("\tVMOVQ X%d, %d(DI)", k, off(xOrder(k, 0)))
+		p("\tVPEXTRQ $1, X%d, %d(DI)", k, off(xOrder(k, 1)))
+		p("\tVEXTRACTI128 $1, Y%d, X15", k)
+		p("\tVMOVQ X15, %d(DI)", off(xOrder(k, 2)))
+		p("\tVPEXTRQ $1, X15, %d(DI)", off(xOrder(k, 3)))
+	}
+}
+
+// emitTheta XORs D into every lane. Y7 holds C[1..4] and Y8 C[0] in all
+// lanes; Y9 and Y10 are their rotations by one.
+func emitTheta() {
+	p("\t// Theta.")
+	if avx512 {
+		p("\tVMOVDQA Y1, Y7")
+		p("\tVPTERNLOGQ $%#02x, Y4, Y3, Y7", xor3)
+		p("\tVPTERNLOGQ $%#02x, Y6, Y5, Y7", xor3)
+	} else {
+		p("\tVPXOR Y3, Y1, Y7")
+		for k := 4; k < 7; k++ {
+			p("\tVPXOR Y%d, Y7, Y7", k)
+		}
+	}
+	p("\tVPSHUFD $0x4e, Y2, Y8")
+	p("\tVPXOR Y2, Y8, Y8")
+	p("\tVPERMQ $0x4e, Y8, Y11")
+	if avx512 {
+		p("\tVPTERNLOGQ $%#02x, Y0, Y11, Y8", xor3)
+	} else {
+		p("\tVPXOR Y11, Y8, Y8")
+		p("\tVPXOR Y0, Y8, Y8")
+	}
+	for _, c := range [][2]int{{7, 9}, {8, 10}} {
+		if avx512 {
+			p("\tVPROLQ $1, Y%d, Y%d", c[0], c[1])
+			continue
+		}
+		p("\tVPSRLQ $63, Y%d, Y11", c[0])
+		p("\tVPADDQ Y%d, Y%d, Y%d", c[0], c[0], c[1])
+		p("\tVPOR Y11, Y%d, Y%d", c[1], c[1])
+	}
+
+	// D[1..4] = C[0..3] ^ rol(C[2..4, 0], 1).
+	p("\tVPERMQ $%#02x, Y7, Y11", permImm([4]int{3, 0, 1, 2}))
+	p("\tVPBLENDD $0x03, Y8, Y11, Y11")
+	p("\tVPERMQ $%#02x, Y9, Y12", permImm([4]int{1, 2, 3, 0}))
+	p("\tVPBLENDD $0xc0, Y10, Y12, Y12")
+	// D[0] = C[4] ^ rol(C[1], 1), in all lanes.
+	p("\tVPERMQ $0xff, Y7, Y13")
+	p("\tVPERMQ $0x00, Y9, Y14")
+
+	if avx512 {
+		// XOR both halves of D in at once.
+		for _, k := range []int{0, 2} {
+			p("\tVPTERNLOGQ $%#02x, Y14, Y13, Y%d", xor3, k)
+		}
+		for _, k := range []int{1, 3, 4, 5, 6} {
+			p("\tVPTERNLOGQ $%#02x, Y12, Y11, Y%d", xor3, k)
+		}
+		return
+	}
+	p("\tVPXOR Y12, Y11, Y11")
+	p("\tVPXOR Y14, Y13, Y13")
+	for _, k := range []int{0, 2} {
+		p("\tVPXOR Y13, Y%d, Y%d", k, k)
+	}
+	for _, k := range []int{1, 3, 4, 5, 6} {
+		p("\tVPXOR Y11, Y%d, Y%d", k, k)
+	}
+}
+
+// emitRhoPi rotates every lane, then renames registers along pi's cycle,
+// shuffling each from x order into y order.
+func emitRhoPi() {
+	p("\t// Rho.")
+	for k := 1; k < 7; k++ {
+		if avx512 {
+			p("\tVPROLVQ rhoLeft<>+%d(SB), Y%d, Y%d", 32*(k-1), k, k)
+			continue
+		}
+		p("\tVPSLLVQ rhoLeft<>+%d(SB), Y%d, Y7", 32*(k-1), k)
+		p("\tVPSRLVQ rhoRight<>+%d(SB), Y%d, Y%d", 32*(k-1), k, k)
+		p("\tVPOR Y7, Y%d, Y%d", k, k)
+	}
+
+	p("\t// Pi.")
+	// New Y1 comes from Y6, which the cycle overwrites first, so it goes
+	// through Y7.
+	emitPi(1, 6, "Y7")
+	for k := 6; k > 1; k-- {
+		emitPi(k, k-1, fmt.Sprintf("Y%d", k))
+	}
+	p("\tVMOVDQA Y7, Y1")
+}
+
+// emitPi writes to dst the new register k, in y order, from the old
+// register src, in x order.
+func emitPi(k, src int, dst string) {
+	var lanes [4]int
+	for j := range lanes {
+		from := pos{}
+		for x := 0; x < 5; x++ {
+			for y := 0; y < 5; y++ {
+				if pi(pos{x, y}) == yOrder(k, j) {
+					from = pos{x, y}
+				}
+			}
+		}
+		r, l := locate(xOrder, from, j)
+		if r != src {
+			panic("pi does not map register to register")
+		}
+		lanes[j] = l
+	}
+	emitPerm(lanes, fmt.Sprintf("Y%d", src), dst)
+}
+
+// emitPerm shuffles src into dst, or copies it if lanes is the identity.
+func emitPerm(lanes [4]int, src, dst string) {
+	if lanes == [4]int{0, 1, 2, 3} {
+		if src != dst {
+			p("\tVMOVDQA %s, %s", src, dst)
+		}
+		return
+	}
+	p("\tVPERMQ $%#02x, %s, %s", permImm(lanes), src, dst)
+}
+
+// emitChiIota applies chi to Y0–Y6 in y order and returns them to x order,
+// then XORs the round constant at CX into Y0. The new Y2–Y6 are built in
+// Y7–Y11 while the old ones are still read; Y12 and Y13 are scratch.
+func emitChiIota() {
+	p("\t// Chi.")
+	for k := 2; k < 7; k++ {
+		emitChi(k, fmt.Sprintf("Y%d", k+5))
+	}
+	p("\tVMOVDQA Y7, Y2")
+	for k := 3; k < 7; k++ {
+		var lanes [4]int
+		for j := range lanes {
+			_, lanes[j] = locate(yOrder, xOrder(k, j), j)
+		}
+		emitPerm(lanes, fmt.Sprintf("Y%d", k+5), fmt.Sprintf("Y%d", k))
+	}
+	// Row 0 last: Y1's neighbours include Y0, and Y0's are in Y1.
+	emitChi(1, "Y7")
+	emitChi(0, "Y8")
+	p("\tVMOVDQA Y7, Y1")
+
+	p("\t// Iota.")
+	if avx512 {
+		p("\tVPXORQ.BCST (CX), Y8, Y0")
+		return
+	}
+	p("\tVPBROADCASTQ (CX), Y12")
+	p("\tVPXOR Y12, Y8, Y0")
+}
+
+// emitChi writes chi of register k to dst: B ^ (~B[x+1] & B[x+2]).
+func emitChi(k int, dst string) {
+	var next1, next2 [4]pos
+	for j := range next1 {
+		q := yOrder(k, j)
+		next1[j] = pos{(q.x + 1) % 5, q.y}
+		next2[j] = pos{(q.x + 2) % 5, q.y}
+	}
+	if avx512 && k >= 2 {
+		// Both neighbours of a lane of Y2–Y6 sit in the same lane of
+		// other registers, so chi runs as one masked VPTERNLOGQ per pair
+		// of neighbour registers instead of gathering them.
+		chi := ternImm(func(a, b, c uint8) uint8 { return a ^ (^b & c) })
+		p("\tVMOVDQA Y%d, %s", k, dst)
+		for _, g := range chiGroups(k) {
+			p("\tVPTERNLOGQ $%#02x, Y%d, Y%d, K%d, %s", chi, g.next2, g.next1, maskReg(g.mask), dst)
+		}
+		return
+	}
+	emitGather(next1, dst)
+	emitGather(next2, "Y12")
+	if avx512 {
+		chi := ternImm(func(a, b, c uint8) uint8 { return b ^ (^a & c) })
+		p("\tVPTERNLOGQ $%#02x, Y12, Y%d, %s", chi, k, dst)
+		return
+	}
+	p("\tVPANDN Y12, %s, %s", dst, dst)
+	p("\tVPXOR Y%d, %s, %s", k, dst, dst)
+}
+
+// chiGroup is a set of lanes, as a bit mask, of one of Y2–Y6 whose chi
+// neighbours x+1 and x+2 sit in the registers next1 and next2.
+type chiGroup struct{ mask, next1, next2 int }
+
+// chiGroups splits the lanes of register k, in y order, by the registers
+// holding their chi neighbours.
+func chiGroups(k int) []chiGroup {
+	var groups []chiGroup
+lanes:
+	for j := 0; j < 4; j++ {
+		q := yOrder(k, j)
+		k1, l1 := locate(yOrder, pos{(q.x + 1) % 5, q.y}, j)
+		k2, l2 := locate(yOrder, pos{(q.x + 2) % 5, q.y}, j)
+		if l1 != j || l2 != j {
+			panic("chi neighbour in another lane")
+		}
+		for i := range groups {
+			if groups[i].next1 == k1 && groups[i].next2 == k2 {
+				groups[i].mask |= 1 << j
+				continue lanes
+			}
+		}
+		groups = append(groups, chiGroup{1 << j, k1, k2})
+	}
+	return groups
+}
+
+// chiMasks lists the lane masks chiGroups uses, in the order they are
+// loaded into K1, K2 and so on.
+func chiMasks() []int {
+	var masks []int
+	for k := 2; k < 7; k++ {
+		for _, g := range chiGroups(k) {
+			if !slices.Contains(masks, g.mask) {
+				masks = append(masks, g.mask)
+			}
+		}
+	}
+	return masks
+}
+
+// maskReg returns the K register holding mask.
+func maskReg(mask int) int {
+	return slices.Index(chiMasks(), mask) + 1
+}
+
+// emitGather builds in dst the vector whose lane j is state lane want[j],
+// reading registers in y order. Lanes from one register move together with
+// a VPERMQ, into dst for the first register and Y13 for the rest, and the
+// registers merge with VPBLENDD.
+func emitGather(want [4]pos, dst string) {
+	type group struct {
+		k     int
+		lanes [4]int
+		mask  int
+	}
+	var groups []*group
+	for j, q := range want {
+		k, l := locate(yOrder, q, j)
+		var g *group
+		for _, h := range groups {
+			if h.k == k {
+				g = h
+			}
+		}
+		if g == nil {
+			g = &group{k: k, lanes: [4]int{0, 1, 2, 3}}
+			groups = append(groups, g)
+		}
+		g.lanes[j] = l
+		g.mask |= 3 << (2 * j)
+	}
+	acc := ""
+	for i, g := range groups {
+		src := fmt.Sprintf("Y%d", g.k)
+		if g.lanes != [4]int{0, 1, 2, 3} {
+			tmp := "Y13"
+			if i == 0 {
+				tmp = dst
+			}
+			emitPerm(g.lanes, src, tmp)
+			src = tmp
+		}
+		if i == 0 {
+			acc = src
+			continue
+		}
+		p("\tVPBLENDD $%#02x, %s, %s, %s", g.mask, src, acc, dst)
+		acc = dst
+	}
+	if acc != dst {
+		p("\tVMOVDQA %s, %s", acc, dst)
+	}
+}
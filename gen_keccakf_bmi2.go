@@ -2,7 +2,8 @@
 
 // gen_keccakf_bmi2.go generates keccakf_amd64_bmi2.s — a BMI2-optimized
 // Keccak-f[1600] permutation using RORXQ and ANDNQ.
-// Fully unrolled (all 24 rounds).
+// Fully unrolled (all 24 rounds), with an entry point at round 12 for the
+// reduced-round Keccak-p[1600, 12] used by TurboSHAKE and KangarooTwelve.
 //
 // Key optimizations:
 //   - D values kept in registers (R14, R15, BP, SI, DX), not on stack
@@ -76,11 +77,13 @@ func main() {
 	p("#include \"textflag.h\"")
 	p("")
 
-	// Single function: keccakF1600BMI2(a *[200]byte, buf *byte)
+	// Single function: keccakF1600BMI2(a *[200]byte, buf *byte, rounds int)
 	// When buf != nil, XORs rate bytes into state before permuting.
 	// When buf == nil, just permutes.
-	p("// func keccakF1600BMI2(a *[200]byte, buf *byte)")
-	p("TEXT ·keccakF1600BMI2(SB), NOSPLIT, $%d-16", fsize)
+	// rounds is 24 for Keccak-f[1600] or 12 for Keccak-p[1600, 12], which
+	// enters the unrolled sequence at round 12.
+	p("// func keccakF1600BMI2(a *[200]byte, buf *byte, rounds int)")
+	p("TEXT ·keccakF1600BMI2(SB), NOSPLIT, $%d-24", fsize)
 	p("\tMOVQ a+0(FP), DI")
 	p("\tMOVQ buf+8(FP), BX")
 	p("\tTESTQ BX, BX")
@@ -93,10 +96,16 @@ func main() {
 	}
 	p("")
 	p("rounds:")
+	p("\tCMPQ rounds+16(FP), $12")
+	p("\tJEQ round12")
 
 	for round := 0; round < 24; round++ {
 		p("")
 		p("\t// Round %d", round)
+		if round == 12 {
+			// Round 12 reads from the array, like round 0, so it is a valid entry point.
+			p("round12:")
+		}
 		srcArray := (round % 2) == 0
 		emitRound(srcArray, round)
 	}
@@ -0,0 +1,14 @@
+//go:build !race && !keccakdebug
+
+package keccak
+
+// debugChecks enables precondition checks, such as WriteAligned's, that
+// normal builds skip for speed.
+const debugChecks = false
+
+// useGuard detects concurrent use of a Hasher in race and keccakdebug builds.
+// In normal builds it is empty and its methods compile to nothing.
+type useGuard struct{}
+
+func (*useGuard) enter() {}
+func (*useGuard) exit()  {}
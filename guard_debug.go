@@ -0,0 +1,25 @@
+//go:build race || keccakdebug
+
+package keccak
+
+import "sync/atomic"
+
+// debugChecks enables precondition checks, such as WriteAligned's, that
+// normal builds skip for speed.
+const debugChecks = true
+
+// useGuard detects concurrent use of a Hasher: enter panics if another call
+// is already in progress. It is only compiled into race and keccakdebug builds.
+type useGuard struct {
+	busy int32
+}
+
+func (g *useGuard) enter() {
+	if !atomic.CompareAndSwapInt32(&g.busy, 0, 1) {
+		panic("keccak: concurrent use of Hasher")
+	}
+}
+
+func (g *useGuard) exit() {
+	atomic.StoreInt32(&g.busy, 0)
+}
@@ -0,0 +1,52 @@
+//go:build race || keccakdebug
+
+package keccak
+
+import "testing"
+
+func TestGuardDetectsConcurrentUse(t *testing.T) {
+	// Simulate a call in progress on another goroutine.
+	var h Hasher
+	h.guard.enter()
+
+	for name, f := range map[string]func(){
+		"Write":  func() { h.Write([]byte("x")) },
+		"Sum256": func() { h.Sum256() },
+		"Read":   func() { h.Read(make([]byte, 32)) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s during another call did not panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+
+	// Reset clears the guard.
+	h.Reset()
+	h.Write([]byte("x"))
+	if got, want := h.Sum256(), Sum256([]byte("x")); got != want {
+		t.Fatalf("after Reset: got %x, want %x", got, want)
+	}
+}
+
+func TestWriteAlignedChecksPreconditions(t *testing.T) {
+	for name, f := range map[string]func(h *Hasher){
+		"partial block": func(h *Hasher) { h.WriteAligned(make([]byte, rate+1)) },
+		"after carry": func(h *Hasher) {
+			h.Write([]byte("x"))
+			h.WriteAligned(make([]byte, rate))
+		},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("WriteAligned with %s did not panic", name)
+				}
+			}()
+			f(new(Hasher))
+		}()
+	}
+}
@@ -0,0 +1,491 @@
+package keccak
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+// useASM is set by platform-specific init when an assembly permutation is
+// available. keccakF1600Dispatch and keccakP12 use the generic Go
+// permutation when it is false, and on builds without assembly.
+var useASM bool
+
+// permutation replaces the platform Keccak-f[1600] when set; see
+// SetPermutation.
+var permutation func(*[200]byte)
+
+// SetPermutation routes every Keccak-f[1600] call in the package through f,
+// or restores the platform's choice when f is nil. It exists to benchmark
+// and cross-check permutation backends within one binary; the default path
+// only pays a nil check, made by the assembly entry point before it jumps to
+// the kernel. f runs on a heap copy of the state, which costs an allocation
+// per call, so compare backends against each other through it rather than
+// against the default path. The 12-round Keccak-p[1600, 12] of TurboSHAKE
+// and KangarooTwelve is not affected.
+//
+// SetPermutation must not be called concurrently with hashing.
+func SetPermutation(f func(*[200]byte)) { permutation = f }
+
+// keccakF1600 applies Keccak-f[1600] to a. Like xorAndPermute it is a single
+// call, so it inlines and the default path reaches the kernel directly.
+func keccakF1600(a *[200]byte) {
+	keccakF1600Dispatch(a, nil, 24)
+}
+
+// xorAndPermute XORs rate bytes of buf into state, then applies
+// Keccak-f[1600].
+func xorAndPermute(state *[200]byte, buf *byte) {
+	keccakF1600Dispatch(state, buf, 24)
+}
+
+// permuteSlow is where keccakF1600Dispatch goes when useASM is false or a
+// SetPermutation backend is installed. The assembly entry points that jump
+// here are declared noescape, so it must not let a or buf escape.
+func permuteSlow(a *[200]byte, buf *byte, rounds int) {
+	var block *[rate]byte
+	if buf != nil {
+		block = (*[rate]byte)(unsafe.Slice(buf, rate))
+	}
+	if permutation != nil && rounds == 24 {
+		if block != nil {
+			xorIn(a, block[:])
+		}
+		permuteOverride(a)
+		return
+	}
+	keccakGeneric(a, block, rounds)
+}
+
+// permuteOverride applies the SetPermutation backend to a copy of a. Passing
+// a itself to an unknown function would move every sponge state to the heap,
+// default path included; the copy confines that cost to this branch.
+//
+//go:noinline
+func permuteOverride(a *[200]byte) {
+	s := *a
+	permutation(&s)
+	*a = s
+}
+
+// sponge is the core Keccak-256 sponge state behind Sum256 and Hasher.
+type sponge struct {
+	state     [200]byte
+	buf       [rate]byte
+	absorbed  int
+	squeezing bool
+	permuted  bool // a block has been absorbed, so state may be non-zero
+	readIdx   int  // index into state for next Read byte
+}
+
+// Reset resets the sponge to its initial state. The rate buffer is zeroed
+// too, so no absorbed input lingers in memory after a reset.
+func (s *sponge) Reset() {
+	if !s.permuted && !s.squeezing {
+		// Nothing has touched the state, so it is still zero and only the
+		// buffer, holding the input, needs clearing. This is the common
+		// case for pooled hashers of short messages.
+		s.buf = [rate]byte{}
+		s.absorbed = 0
+		return
+	}
+	*s = sponge{}
+}
+
+// Write absorbs data into the sponge.
+// Panics if called after Read.
+func (s *sponge) Write(p []byte) (int, error) {
+	if s.squeezing {
+		panic("keccak: Write after Read")
+	}
+	n := len(p)
+	if s.absorbed > 0 {
+		x := copy(s.buf[s.absorbed:rate], p)
+		s.absorbed += x
+		p = p[x:]
+		if s.absorbed == rate {
+			xorAndPermute(&s.state, &s.buf[0])
+			s.absorbed = 0
+			s.permuted = true
+		}
+	}
+
+	for len(p) >= rate {
+		xorAndPermute(&s.state, &p[0])
+		p = p[rate:]
+		s.permuted = true
+	}
+
+	if len(p) > 0 {
+		s.absorbed = copy(s.buf[:], p)
+	}
+	return n, nil
+}
+
+// writeAligned absorbs whole blocks of p, skipping the carry buffer.
+// See Hasher.WriteAligned for the preconditions.
+func (s *sponge) writeAligned(p []byte) {
+	if s.squeezing {
+		panic("keccak: Write after Read")
+	}
+	if debugChecks && (len(p)%rate != 0 || s.absorbed != 0) {
+		panic("keccak: WriteAligned with a partial block")
+	}
+	for len(p) >= rate {
+		xorAndPermute(&s.state, &p[0])
+		p = p[rate:]
+		s.permuted = true
+	}
+}
+
+// writeZeros absorbs n zero bytes. XORing zeros leaves the state as it is,
+// so whole blocks cost a bare permutation; only a partial block touches the
+// carry buffer, whose bytes past absorbed may be stale and are cleared.
+func (s *sponge) writeZeros(n int) {
+	if s.squeezing {
+		panic("keccak: Write after Read")
+	}
+	if n < 0 {
+		panic("keccak: WriteZeros with negative count")
+	}
+	if s.absorbed > 0 {
+		x := min(rate-s.absorbed, n)
+		clear(s.buf[s.absorbed : s.absorbed+x])
+		s.absorbed += x
+		n -= x
+		if s.absorbed == rate {
+			xorAndPermute(&s.state, &s.buf[0])
+			s.absorbed = 0
+			s.permuted = true
+		}
+	}
+	for ; n >= rate; n -= rate {
+		keccakF1600(&s.state)
+		s.permuted = true
+	}
+	if n > 0 {
+		clear(s.buf[:n])
+		s.absorbed = n
+	}
+}
+
+// writeLanes absorbs the little-endian encoding of lanes. Whole blocks that
+// start on a block boundary are XORed into the state lane by lane.
+func (s *sponge) writeLanes(lanes []uint64) {
+	if s.squeezing {
+		panic("keccak: Write after Read")
+	}
+	var b [8]byte
+	for len(lanes) > 0 {
+		if s.absorbed == 0 && len(lanes) >= rate/8 {
+			for i, v := range lanes[:rate/8] {
+				binary.LittleEndian.PutUint64(s.state[i*8:], binary.LittleEndian.Uint64(s.state[i*8:])^v)
+			}
+			keccakF1600(&s.state)
+			s.permuted = true
+			lanes = lanes[rate/8:]
+			continue
+		}
+		binary.LittleEndian.PutUint64(b[:], lanes[0])
+		s.Write(b[:])
+		lanes = lanes[1:]
+	}
+}
+
+// Sum256 finalizes and returns the 32-byte Keccak-256 digest.
+// Does not modify the sponge state.
+// Panics if called after Read.
+func (s *sponge) Sum256() [32]byte {
+	if s.squeezing {
+		panic("keccak: Sum after Read")
+	}
+	if !s.permuted {
+		// The state is still all zero, so finalize the buffered bytes
+		// alone rather than copying 200 bytes of zeros.
+		var state [200]byte
+		return sum256State(&state, s.buf[:s.absorbed])
+	}
+	// Pad a copy of the tail, as sum256State does, so the sponge is left
+	// exactly as it was.
+	var block [rate]byte
+	copy(block[:], s.buf[:s.absorbed])
+	block[s.absorbed] = 0x01
+	block[rate-1] ^= 0x80
+	state := s.state
+	xorAndPermute(&state, &block[0])
+	return [32]byte(state[:32])
+}
+
+// padBuf completes the buffered bytes to a padded final block in place, for
+// padAndSqueeze. Bytes of buf past absorbed are never read otherwise, so
+// this leaves the absorbed input intact.
+func (s *sponge) padBuf() {
+	clear(s.buf[s.absorbed:])
+	s.buf[s.absorbed] = 0x01
+	s.buf[rate-1] ^= 0x80
+}
+
+// sumInPlace finalizes the live state without copying it and returns the
+// digest. The sponge is left squeezing, positioned after the digest.
+// Panics if called after Read.
+func (s *sponge) sumInPlace() [32]byte {
+	if s.squeezing {
+		panic("keccak: Sum after Read")
+	}
+	s.padAndSqueeze()
+	s.readIdx = 32
+	return [32]byte(s.state[:32])
+}
+
+// Sum appends the current Keccak-256 digest to b and returns the resulting slice.
+// Does not modify the sponge state.
+func (s *sponge) Sum(b []byte) []byte {
+	d := s.Sum256()
+	return append(b, d[:]...)
+}
+
+// Size returns the number of bytes Sum will produce (32).
+func (s *sponge) Size() int { return Size }
+
+// BlockSize returns the sponge rate in bytes (136).
+func (s *sponge) BlockSize() int { return BlockSize }
+
+// Read squeezes an arbitrary number of bytes from the sponge.
+// On the first non-empty call, it pads and permutes, transitioning from
+// absorbing to squeezing. Subsequent calls to Write will panic. An empty
+// Read does nothing. It never returns an error.
+func (s *sponge) Read(out []byte) (int, error) {
+	if len(out) == 0 {
+		return 0, nil
+	}
+	if !s.squeezing {
+		s.padAndSqueeze()
+	}
+
+	n := len(out)
+	for len(out) > 0 {
+		// Permute only once more output is wanted, so a Read ending on a
+		// block boundary leaves the next block for the next call.
+		if s.readIdx == rate {
+			keccakF1600(&s.state)
+			s.readIdx = 0
+		}
+		x := copy(out, s.state[s.readIdx:rate])
+		s.readIdx += x
+		out = out[x:]
+	}
+	return n, nil
+}
+
+func (s *sponge) padAndSqueeze() {
+	s.padBuf()
+	xorAndPermute(&s.state, &s.buf[0])
+	s.squeezing = true
+	s.readIdx = 0
+}
+
+// sum256State absorbs data into the all-zero state and squeezes the digest.
+func sum256State(state *[200]byte, data []byte) [32]byte {
+	if len(data) < rate {
+		// Single padded block: the state is all zero, so absorbing is a copy.
+		copy(state[:], data)
+		state[len(data)] = 0x01
+		state[rate-1] ^= 0x80
+		keccakF1600(state)
+		return [32]byte(state[:32])
+	}
+
+	for len(data) >= rate {
+		xorAndPermute(state, &data[0])
+		data = data[rate:]
+	}
+
+	// Pad the tail in a block of its own, so the state is only touched by
+	// the fused XOR-and-permute.
+	var block [rate]byte
+	copy(block[:], data)
+	block[len(data)] = 0x01
+	block[rate-1] ^= 0x80
+	xorAndPermute(state, &block[0])
+
+	return [32]byte(state[:32])
+}
+
+// Sum256 computes the Keccak-256 hash of data with zero heap allocations.
+func Sum256(data []byte) [32]byte {
+	var state [200]byte
+	if genericBelowThreshold(len(data)) {
+		return sum256Generic(&state, data)
+	}
+	return sum256State(&state, data)
+}
+
+// Sum256WithState computes the Keccak-256 hash of data using the
+// caller-owned scratch as the sponge state, so it never touches the heap even
+// where escape analysis would move a local state there. scratch is zeroed
+// first and holds the final sponge state on return.
+func Sum256WithState(scratch *[200]byte, data []byte) [32]byte {
+	*scratch = [200]byte{}
+	return sum256State(scratch, data)
+}
+
+// Hasher is a streaming Keccak-256 hasher. The zero value is ready to use.
+// A Hasher must not be used concurrently; see SafeHasher.
+//
+// A Hasher absorbs until its first Read or SumInPlace, which pads and
+// permutes once and switches it to squeezing for good. While absorbing,
+// Sum256, Sum and Snapshot finalize a copy of the state, so they may be
+// called any number of times between writes and the first Read still
+// starts at byte 0 of the output. While squeezing, Read continues the
+// output stream (after the digest, for SumInPlace), and Write, Sum256, Sum,
+// Snapshot and SumInPlace panic. Reset returns to absorbing.
+type Hasher struct {
+	guard useGuard // concurrent-use detection in race and keccakdebug builds
+	sponge
+}
+
+// Reset resets the hasher to its initial state.
+func (h *Hasher) Reset() {
+	h.sponge.Reset()
+	// Also clears a guard left set by a panic (e.g. Write after Read).
+	h.guard = useGuard{}
+}
+
+// Write absorbs data into the hasher. It always absorbs all of p and returns
+// len(p), nil. It never allocates and does not retain p, so p may be a reused
+// read buffer.
+// Panics if called after Read.
+func (h *Hasher) Write(p []byte) (int, error) {
+	h.guard.enter()
+	n, err := h.sponge.Write(p)
+	h.guard.exit()
+	return n, err
+}
+
+// WriteAligned absorbs p, a whole number of BlockSize-byte blocks, straight
+// into the state. It requires len(p) to be a multiple of BlockSize and every
+// earlier write to have ended on a block boundary; a final unaligned Write may
+// follow. If either precondition fails the digest is wrong: race and
+// keccakdebug builds panic instead.
+// Panics if called after Read.
+func (h *Hasher) WriteAligned(p []byte) {
+	h.guard.enter()
+	h.sponge.writeAligned(p)
+	h.guard.exit()
+}
+
+// Pending returns the number of bytes written since the last permutation,
+// which wait in the carry buffer for the block to fill.
+func (h *Hasher) Pending() int { return h.absorbed }
+
+// Available returns how many more bytes fit before the next permutation.
+// Sizing the next Write to it, and then to multiples of BlockSize, keeps
+// input out of the carry buffer.
+func (h *Hasher) Available() int { return rate - h.absorbed }
+
+// WriteLanes absorbs the 8-byte little-endian encoding of each lane, without
+// encoding them first: full 17-lane blocks go straight into the state.
+// Panics if called after Read.
+func (h *Hasher) WriteLanes(lanes []uint64) {
+	h.guard.enter()
+	h.sponge.writeLanes(lanes)
+	h.guard.exit()
+}
+
+// WriteZeros absorbs n zero bytes without materializing them, for padding
+// or regions whose content is known to be zero. Each whole block costs one
+// permutation and no memory traffic.
+// Panics if n is negative or if called after Read.
+func (h *Hasher) WriteZeros(n int) {
+	h.guard.enter()
+	h.sponge.writeZeros(n)
+	h.guard.exit()
+}
+
+// WriteByte absorbs a single byte. It implements io.ByteWriter and never returns an error.
+// Panics if called after Read.
+func (h *Hasher) WriteByte(c byte) error {
+	h.guard.enter()
+	h.sponge.Write([]byte{c})
+	h.guard.exit()
+	return nil
+}
+
+// WriteUint32 absorbs the 4-byte little-endian encoding of v.
+// Panics if called after Read.
+func (h *Hasher) WriteUint32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	h.guard.enter()
+	h.sponge.Write(b[:])
+	h.guard.exit()
+}
+
+// WriteUint64 absorbs the 8-byte little-endian encoding of v.
+// Panics if called after Read.
+func (h *Hasher) WriteUint64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	h.guard.enter()
+	h.sponge.Write(b[:])
+	h.guard.exit()
+}
+
+// Sum256 finalizes and returns the 32-byte Keccak-256 digest.
+// Does not modify the hasher state.
+func (h *Hasher) Sum256() [32]byte {
+	h.guard.enter()
+	d := h.sponge.Sum256()
+	h.guard.exit()
+	return d
+}
+
+// Snapshot returns the Keccak-256 digest of everything written so far and
+// leaves the hasher able to keep absorbing, so it can be called after every
+// appended record of a log. It is Sum256 under a name that states this
+// intent: each call costs one 200-byte state copy and one permutation, and
+// never affects later snapshots.
+func (h *Hasher) Snapshot() [32]byte {
+	return h.Sum256()
+}
+
+// SumInPlace finalizes the hasher and returns the 32-byte Keccak-256 digest.
+// Unlike Sum256 it pads and permutes the live state instead of a copy, so it
+// is cheaper when each input is finalized exactly once. Afterwards the hasher
+// is spent: Write and Sum panic until Reset.
+func (h *Hasher) SumInPlace() [32]byte {
+	h.guard.enter()
+	d := h.sponge.sumInPlace()
+	h.guard.exit()
+	return d
+}
+
+// Sum256Reset returns the 32-byte Keccak-256 digest and resets the hasher.
+// It is equivalent to Sum256 followed by Reset but finalizes the live state,
+// skipping Sum256's defensive copy.
+func (h *Hasher) Sum256Reset() [32]byte {
+	h.guard.enter()
+	d := h.sponge.sumInPlace()
+	h.sponge.Reset()
+	h.guard.exit()
+	return d
+}
+
+// Sum appends the current Keccak-256 digest to b and returns the resulting slice.
+// Does not modify the hasher state.
+func (h *Hasher) Sum(b []byte) []byte {
+	h.guard.enter()
+	b = h.sponge.Sum(b)
+	h.guard.exit()
+	return b
+}
+
+// Read squeezes an arbitrary number of bytes from the sponge.
+// On the first non-empty call, it pads and permutes, transitioning from
+// absorbing to squeezing. Subsequent calls to Write will panic. An empty
+// Read does nothing. It never returns an error.
+func (h *Hasher) Read(out []byte) (int, error) {
+	h.guard.enter()
+	n, err := h.sponge.Read(out)
+	h.guard.exit()
+	return n, err
+}
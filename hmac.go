@@ -0,0 +1,30 @@
+package keccak
+
+// HMAC256 computes HMAC (RFC 2104) with Keccak-256 as the hash function and a
+// block size of 136 bytes. Keys longer than the block size are hashed first.
+func HMAC256(key, data []byte) [32]byte {
+	var k [rate]byte
+	if len(key) > rate {
+		d := Sum256(key)
+		copy(k[:], d[:])
+	} else {
+		copy(k[:], key)
+	}
+
+	var pad [rate]byte
+	for i := range pad {
+		pad[i] = k[i] ^ 0x36
+	}
+	var h Hasher
+	h.Write(pad[:])
+	h.Write(data)
+	inner := h.Sum256()
+
+	for i := range pad {
+		pad[i] = k[i] ^ 0x5c
+	}
+	h.Reset()
+	h.Write(pad[:])
+	h.Write(inner[:])
+	return h.Sum256()
+}
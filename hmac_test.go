@@ -0,0 +1,98 @@
+package keccak
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// RFC 4231 test case inputs, checked against crypto/hmac over x/crypto's Keccak-256.
+func TestHMAC256(t *testing.T) {
+	tests := []struct {
+		key, data []byte
+	}{
+		{bytes.Repeat([]byte{0x0b}, 20), []byte("Hi There")},
+		{[]byte("Jefe"), []byte("what do ya want for nothing?")},
+		{bytes.Repeat([]byte{0xaa}, 20), bytes.Repeat([]byte{0xdd}, 50)},
+		{unhex("0102030405060708090a0b0c0d0e0f10111213141516171819"), bytes.Repeat([]byte{0xcd}, 50)},
+		{bytes.Repeat([]byte{0x0c}, 20), []byte("Test With Truncation")},
+		{bytes.Repeat([]byte{0xaa}, 131), []byte("Test Using Larger Than Block-Size Key - Hash Key First")},
+		{bytes.Repeat([]byte{0xaa}, 131), []byte("This is a test using a larger than block-size key and a larger than block-size data. The key needs to be hashed before being used by the HMAC algorithm.")},
+		{nil, nil},
+		{bytes.Repeat([]byte{0x42}, rate), []byte("key of exactly one block")},
+		{bytes.Repeat([]byte{0x42}, rate+1), []byte("key one byte over the block size")},
+	}
+	for i, tt := range tests {
+		ref := hmac.New(sha3.NewLegacyKeccak256, tt.key)
+		ref.Write(tt.data)
+		want := ref.Sum(nil)
+		if got := HMAC256(tt.key, tt.data); !bytes.Equal(got[:], want) {
+			t.Errorf("case %d: HMAC256 = %x, want %x", i+1, got, want)
+		}
+	}
+}
+
+// TestWorkIndependentOfContent checks, as a deterministic proxy for timing,
+// that the permutations run for a keyed hash depend only on lengths.
+func TestWorkIndependentOfContent(t *testing.T) {
+	defer SetPermutation(nil)
+	calls := 0
+	SetPermutation(func(a *[200]byte) {
+		calls++
+		keccakF1600Generic(a)
+	})
+	count := func(key, msg []byte) int {
+		calls = 0
+		HMAC256(key, msg)
+		var h Hasher
+		for p := msg; len(p) > 0; p = p[min(7, len(p)):] {
+			h.Write(p[:min(7, len(p))])
+		}
+		h.Sum256()
+		return calls
+	}
+	for _, n := range []int{0, 1, rate - 1, rate, 3*rate + 5} {
+		zeros := make([]byte, n)
+		ones := bytes.Repeat([]byte{0xff}, n)
+		mixed := make([]byte, n)
+		for i := range mixed {
+			mixed[i] = byte(i * 131)
+		}
+		want := count(zeros, zeros)
+		if got := count(ones, ones); got != want {
+			t.Errorf("len=%d: %d permutations for 0xff bytes, %d for zeros", n, got, want)
+		}
+		if got := count(mixed, mixed); got != want {
+			t.Errorf("len=%d: %d permutations for mixed bytes, %d for zeros", n, got, want)
+		}
+	}
+}
+
+// BenchmarkHMAC256Content times a fixed-length keyed hash over inputs of
+// different content; the results should agree within noise.
+func BenchmarkHMAC256Content(b *testing.B) {
+	const n = 1024
+	key := make([]byte, 32)
+	for _, tc := range []struct {
+		name string
+		fill func(i int) byte
+	}{
+		{"zeros", func(int) byte { return 0 }},
+		{"ones", func(int) byte { return 0xff }},
+		{"mixed", func(i int) byte { return byte(i * 131) }},
+	} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = tc.fill(i)
+			key[i%len(key)] = tc.fill(i)
+		}
+		b.Run(tc.name, func(b *testing.B) {
+			b.SetBytes(n)
+			for b.Loop() {
+				HMAC256(key, data)
+			}
+		})
+	}
+}
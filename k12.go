@@ -0,0 +1,132 @@
+package keccak
+
+const (
+	k12ChunkSize = 8192
+
+	// k12ParallelLeaves is the minimum number of whole leaves in one write
+	// before they are hashed concurrently.
+	k12ParallelLeaves = 8
+)
+
+// k12NodeMarker follows the first chunk in the final node when the input
+// spans more than one chunk.
+var k12NodeMarker = [8]byte{0x03}
+
+// K12 computes KangarooTwelve (RFC 9861) of data with the given
+// customization string and returns outputLen bytes.
+// Inputs longer than 8 KiB are split into chunks whose leaf hashes are
+// computed in parallel.
+func K12(data, customization []byte, outputLen int) []byte {
+	var k k12
+	k.init()
+	k.write(data)
+	k.write(customization)
+	k.write(lengthEncode(uint64(len(customization))))
+	out := make([]byte, outputLen)
+	k.read(out)
+	return out
+}
+
+// k12 is the streaming KangarooTwelve tree: the final node absorbs the first
+// chunk followed by the chaining values of every later chunk (leaf).
+type k12 struct {
+	final  keccakSponge
+	leaf   keccakSponge
+	n      uint64 // total bytes absorbed
+	leaves uint64 // chaining values absorbed into the final node
+}
+
+func (k *k12) init() {
+	k.final = newTurboShake(turboShake128Rate, 0x07)
+	k.leaf = newTurboShake(turboShake128Rate, 0x0B)
+}
+
+func (k *k12) write(p []byte) {
+	for len(p) > 0 {
+		if k.n < k12ChunkSize {
+			m := min(k12ChunkSize-int(k.n), len(p))
+			k.final.absorb(p[:m])
+			k.n += uint64(m)
+			p = p[m:]
+			continue
+		}
+		if k.n == k12ChunkSize {
+			k.final.absorb(k12NodeMarker[:])
+		}
+
+		off := int((k.n - k12ChunkSize) % k12ChunkSize)
+		if off == 0 && len(p) >= k12ParallelLeaves*k12ChunkSize {
+			m := len(p) / k12ChunkSize * k12ChunkSize
+			k.writeLeaves(p[:m])
+			k.n += uint64(m)
+			p = p[m:]
+			continue
+		}
+
+		m := min(k12ChunkSize-off, len(p))
+		k.leaf.absorb(p[:m])
+		k.n += uint64(m)
+		p = p[m:]
+		if off+m == k12ChunkSize {
+			k.flushLeaf()
+		}
+	}
+}
+
+// flushLeaf absorbs the chaining value of the current leaf into the final node.
+func (k *k12) flushLeaf() {
+	var cv [32]byte
+	k.leaf.squeeze(cv[:])
+	k.final.absorb(cv[:])
+	k.leaves++
+	k.leaf.reset()
+}
+
+// writeLeaves hashes whole chunks of p concurrently and absorbs their
+// chaining values in order.
+func (k *k12) writeLeaves(p []byte) {
+	n := len(p) / k12ChunkSize
+	cvs := make([][32]byte, n)
+	parallelFor(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			leaf := newTurboShake(turboShake128Rate, 0x0B)
+			leaf.absorb(p[i*k12ChunkSize : (i+1)*k12ChunkSize])
+			leaf.squeeze(cvs[i][:])
+		}
+	})
+
+	for i := range cvs {
+		k.final.absorb(cvs[i][:])
+	}
+	k.leaves += uint64(n)
+}
+
+func (k *k12) read(out []byte) {
+	if k.n <= k12ChunkSize {
+		k.final.squeeze(out)
+		return
+	}
+	if (k.n-k12ChunkSize)%k12ChunkSize != 0 {
+		k.flushLeaf()
+	}
+	k.final.absorb(lengthEncode(k.leaves))
+	k.final.absorb([]byte{0xFF, 0xFF})
+	k.final.domain = 0x06
+	k.final.squeeze(out)
+}
+
+// lengthEncode is the KangarooTwelve length_encode: the minimal big-endian
+// encoding of x followed by its length in bytes. lengthEncode(0) is {0}.
+func lengthEncode(x uint64) []byte {
+	var b [9]byte
+	n := 0
+	for v := x; v != 0; v >>= 8 {
+		n++
+	}
+	b[n] = byte(n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(x)
+		x >>= 8
+	}
+	return b[:n+1]
+}
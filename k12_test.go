@@ -0,0 +1,142 @@
+package keccak
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// ptn returns the RFC 9861 test pattern: n bytes of 0x00..0xFA repeated.
+func ptn(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 251)
+	}
+	return b
+}
+
+func unhex(s string) []byte {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func pow(b, e int) int {
+	r := 1
+	for range e {
+		r *= b
+	}
+	return r
+}
+
+// RFC 9861 §5 KangarooTwelve test vectors.
+func TestK12Vectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		m, c    []byte
+		outLen  int
+		lastLen int // compare only the last lastLen bytes when > 0
+		want    string
+	}{
+		{"M=empty,L=32", nil, nil, 32, 0,
+			"1AC2D450FC3B4205D19DA7BFCA1B37513C0803577AC7167F06FE2CE1F0EF39E5"},
+		{"M=empty,L=64", nil, nil, 64, 0,
+			"1AC2D450FC3B4205D19DA7BFCA1B37513C0803577AC7167F06FE2CE1F0EF39E5" +
+				"4269C056B8C82E48276038B6D292966CC07A3D4645272E31FF38508139EB0A71"},
+		{"M=empty,L=10032", nil, nil, 10032, 32,
+			"E8DC563642F7228C84684C898405D3A834799158C079B12880277A1D28E2FF6D"},
+		{"M=ptn(1)", ptn(1), nil, 32, 0,
+			"2BDA92450E8B147F8A7CB629E784A058EFCA7CF7D8218E02D345DFAA65244A1F"},
+		{"M=ptn(17)", ptn(17), nil, 32, 0,
+			"6BF75FA2239198DB4772E36478F8E19B0F371205F6A9A93A273F51DF37122888"},
+		{"M=ptn(17^2)", ptn(pow(17, 2)), nil, 32, 0,
+			"0C315EBCDEDBF61426DE7DCF8FB725D1E74675D7F5327A5067F367B108ECB67C"},
+		{"M=ptn(17^3)", ptn(pow(17, 3)), nil, 32, 0,
+			"CB552E2EC77D9910701D578B457DDF772C12E322E4EE7FE417F92C758F0D59D0"},
+		{"M=ptn(17^4)", ptn(pow(17, 4)), nil, 32, 0,
+			"8701045E22205345FF4DDA05555CBB5C3AF1A771C2B89BAEF37DB43D9998B9FE"},
+		{"M=ptn(17^5)", ptn(pow(17, 5)), nil, 32, 0,
+			"844D610933B1B9963CBDEB5AE3B6B05CC7CBD67CEEDF883EB678A0A8E0371682"},
+		{"M=ptn(17^6)", ptn(pow(17, 6)), nil, 32, 0,
+			"3C390782A8A4E89FA6367F72FEAAF13255C8D95878481D3CD8CE85F58E880AF8"},
+		{"C=ptn(1)", nil, ptn(1), 32, 0,
+			"FAB658DB63E94A246188BF7AF69A133045F46EE984C56E3C3328CAAF1AA1A583"},
+		{"M=FF,C=ptn(41)", []byte{0xFF}, ptn(41), 32, 0,
+			"D848C5068CED736F4462159B9867FD4C20B808ACC3D5BC48E0B06BA0A3762EC4"},
+		{"M=ptn(8191)", ptn(8191), nil, 32, 0,
+			"1B577636F723643E990CC7D6A659837436FD6A103626600EB8301CD1DBE553D6"},
+		{"M=ptn(8192)", ptn(8192), nil, 32, 0,
+			"48F256F6772F9EDFB6A8B661EC92DC93B95EBD05A08A17B39AE3490870C926C3"},
+		{"M=ptn(8192),C=ptn(8189)", ptn(8192), ptn(8189), 32, 0,
+			"3ED12F70FB05DDB58689510AB3E4D23C6C6033849AA01E1D8C220A297FEDCD0B"},
+		{"M=ptn(8192),C=ptn(8190)", ptn(8192), ptn(8190), 32, 0,
+			"6A7C1B6A5CD0D8C9CA943A4A216CC64604559A2EA45F78570A15253D67BA00AE"},
+	}
+	for _, tt := range tests {
+		got := K12(tt.m, tt.c, tt.outLen)
+		if tt.lastLen > 0 {
+			got = got[len(got)-tt.lastLen:]
+		}
+		if want := unhex(tt.want); !bytes.Equal(got, want) {
+			t.Errorf("%s: got %X, want %X", tt.name, got, want)
+		}
+	}
+}
+
+func TestK12Streaming(t *testing.T) {
+	// Splitting the input across writes must not change the tree, whether
+	// leaves are hashed one by one or concurrently.
+	data := ptn(20*k12ChunkSize + 1234)
+	want := K12(data, nil, 32)
+	for _, step := range []int{1000, k12ChunkSize - 1, k12ChunkSize + 1, 9 * k12ChunkSize} {
+		var k k12
+		k.init()
+		for i := 0; i < len(data); i += step {
+			k.write(data[i:min(i+step, len(data))])
+		}
+		k.write(lengthEncode(0))
+		got := make([]byte, 32)
+		k.read(got)
+		if !bytes.Equal(got, want) {
+			t.Errorf("step=%d: got %x, want %x", step, got, want)
+		}
+	}
+}
+
+func TestKeccakP12MatchesGeneric(t *testing.T) {
+	var a, b [200]byte
+	for i := range a {
+		a[i] = byte(i*7 + 1)
+	}
+	b = a
+	for range 3 {
+		keccakP12(&a)
+		keccakF1600GenericRounds(&b, 12)
+		if a != b {
+			t.Fatalf("keccakP12 mismatch:\ngot:  %x\nwant: %x", a, b)
+		}
+	}
+}
+
+func BenchmarkK12(b *testing.B) {
+	for _, size := range []int{4096, 500 * 1024, 16 << 20} {
+		data := ptn(size)
+		b.Run("K12/"+benchName(size), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for b.Loop() {
+				K12(data, nil, 32)
+			}
+		})
+		b.Run("Sum256/"+benchName(size), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for b.Loop() {
+				Sum256(data)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package keccak
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// keccakKATs are fixed Keccak-256 known answers. The lengths around 136 and
+// 272 straddle the rate, where padding bugs hide: 135 bytes leaves exactly one
+// byte for the combined 0x81 pad, 136 needs a whole extra padding block.
+// The boundary digests were cross-checked against x/crypto/sha3.
+var keccakKATs = []struct {
+	name  string
+	input string
+	want  string
+}{
+	{"empty", "", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+	{"abc", "abc", "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"},
+	{"hello", "hello", "1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8"},
+	{"fox", "The quick brown fox jumps over the lazy dog", "4d741b6f1eb29cb2a9b9911c82f56fa8d73b04959d3d9d222895df6c0b28aa15"},
+
+	// Ethereum event topics and function selectors.
+	{"Transfer", "Transfer(address,address,uint256)", "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
+	{"Approval", "Approval(address,address,uint256)", "8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"},
+	{"transfer", "transfer(address,uint256)", "a9059cbb2ab09eb219583f4a59a5d0623ade346d962bcd4e46b11da047c9049b"},
+	{"balanceOf", "balanceOf(address)", "70a08231b98ef4ca268c9cc3f6b4590e4bfec28280db06bb5d45e689f2a360be"},
+	{"approve", "approve(address,uint256)", "095ea7b334ae44009aa867bfb386f5c3b4b443ac6f0ee573fa91c4608fbadfba"},
+	{"transferFrom", "transferFrom(address,address,uint256)", "23b872dd7302113369cda2901243429419bec145408fa8b352b3dd92b66c680b"},
+
+	// Empty trie root (RLP empty string) and empty uncles hash (RLP empty list).
+	{"emptyTrie", "\x80", "56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421"},
+	{"emptyUncles", "\xc0", "1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347"},
+
+	// Rate boundaries.
+	{"a*135", strings.Repeat("a", 135), "34367dc248bbd832f4e3e69dfaac2f92638bd0bbd18f2912ba4ef454919cf446"},
+	{"a*136", strings.Repeat("a", 136), "a6c4d403279fe3e0af03729caada8374b5ca54d8065329a3ebcaeb4b60aa386e"},
+	{"a*137", strings.Repeat("a", 137), "d869f639c7046b4929fc92a4d988a8b22c55fbadb802c0c66ebcd484f1915f39"},
+	{"a*271", strings.Repeat("a", 271), "132f47effd6c8b1b299efa53fe68aece77ec8ae4eb2e294f668eec94f76001e1"},
+	{"a*272", strings.Repeat("a", 272), "cf7fcd4f705ee749930d19ca84561a9bf62516bd90a471545fa2f49fdc7e63c8"},
+	{"a*273", strings.Repeat("a", 273), "5a7b8187d2778e614097fac3097573de1fee4d972304d3360796a857029bb176"},
+	{"a*1000", strings.Repeat("a", 1000), "b6a4ac1f51884d71f30fa397a5e155de3099e11fc0edef5d08b646e621e19de9"},
+}
+
+func TestKeccak256KAT(t *testing.T) {
+	for _, kat := range keccakKATs {
+		t.Run(kat.name, func(t *testing.T) {
+			data := []byte(kat.input)
+			check := func(how string, got [32]byte) {
+				t.Helper()
+				if hex.EncodeToString(got[:]) != kat.want {
+					t.Errorf("%s = %x, want %s", how, got, kat.want)
+				}
+			}
+			check("Sum256", Sum256(data))
+			check("Sum256String", Sum256String(kat.input))
+
+			var h Hasher
+			h.Write(data)
+			check("Hasher.Sum256", h.Sum256())
+			var out [32]byte
+			h.Read(out[:])
+			check("Hasher.Read", out)
+
+			// Byte-at-a-time and split writes exercise the buffered path.
+			for _, split := range []int{1, 7, rate - 1, rate} {
+				h.Reset()
+				for p := data; len(p) > 0; {
+					n := min(split, len(p))
+					h.Write(p[:n])
+					p = p[n:]
+				}
+				check(fmt.Sprintf("Hasher split %d", split), h.Sum256())
+			}
+		})
+	}
+}
+
+// TestKeccak256KATGeneric runs the known answers through the generic
+// permutation on every platform, so a little-endian CI machine with assembly
+// still covers the code path 32-bit and big-endian targets use.
+func TestKeccak256KATGeneric(t *testing.T) {
+	withGeneric(func() {
+		for _, kat := range keccakKATs {
+			data := []byte(kat.input)
+			if got := Sum256(data); hex.EncodeToString(got[:]) != kat.want {
+				t.Errorf("%s: generic Sum256 = %x, want %s", kat.name, got, kat.want)
+			}
+			var h Hasher
+			for _, b := range data {
+				h.WriteByte(b)
+			}
+			if got := h.Sum256(); hex.EncodeToString(got[:]) != kat.want {
+				t.Errorf("%s: generic Hasher = %x, want %s", kat.name, got, kat.want)
+			}
+		}
+	})
+}
@@ -1,7 +1,18 @@
 // Package keccak provides Keccak-256 hashing with platform-specific acceleration.
+//
+// Hashing takes time that depends on the lengths of the input and of the
+// writes that deliver it, never on its content: the absorb and padding code
+// branches only on lengths and buffer positions, and every permutation
+// (generic Go, amd64 BMI2, arm64 SHA3, s390x KIMD) is straight-line code
+// with constant rotations and no data-indexed table lookups. Keyed uses such
+// as HMAC256 therefore leak only lengths; compare their outputs with Equal.
 package keccak
 
-import "hash"
+import (
+	"hash"
+	"io"
+	"unsafe"
+)
 
 // KeccakState wraps the keccak hasher. In addition to the usual hash methods, it also supports
 // Read to get a variable amount of data from the hash state. Read is faster than Sum
@@ -11,10 +22,148 @@ type KeccakState interface {
 	Read([]byte) (int, error)
 }
 
-const rate = 136 // sponge rate for Keccak-256: (1600 - 2*256) / 8
+const (
+	// Size is the size of a Keccak-256 digest in bytes.
+	Size = 32
 
-var _ KeccakState = (*Hasher)(nil)
+	// BlockSize is the Keccak-256 sponge rate in bytes: (1600 - 2*256) / 8.
+	// Streaming writes in multiples of it never go through the carry buffer.
+	BlockSize = 136
+)
+
+const rate = BlockSize
+
+var (
+	_ KeccakState   = (*Hasher)(nil)
+	_ io.ByteWriter = (*Hasher)(nil)
+)
 
 func NewFastKeccak() *Hasher {
 	return &Hasher{}
 }
+
+// NewLegacyKeccak256 returns a Keccak-256 hash.Hash, a drop-in replacement for
+// golang.org/x/crypto/sha3.NewLegacyKeccak256 with the same Write, Sum, Reset,
+// Size and BlockSize behavior. The dynamic type is *Hasher, so it also
+// satisfies KeccakState.
+func NewLegacyKeccak256() hash.Hash {
+	return new(Hasher)
+}
+
+// Sum256String computes the Keccak-256 hash of s without copying it to a []byte.
+func Sum256String(s string) [32]byte {
+	// Sum256 only reads its input, so aliasing the string's bytes is safe.
+	return Sum256(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
+// Sum256Of32 computes the Keccak-256 hash of a single 32-byte value, such as
+// another digest or an Ethereum storage slot key. The input always fits one
+// block, so it is a constant copy, the padding bytes and one permutation.
+func Sum256Of32(in [32]byte) [32]byte {
+	var state [200]byte
+	*(*[32]byte)(state[:]) = in
+	state[32] = 0x01
+	state[rate-1] = 0x80
+	keccakF1600(&state)
+	return [32]byte(state[:32])
+}
+
+// Sum256Domain computes a 256-bit digest of data with the Keccak-256 rate
+// and domain separation byte domain in place of Keccak's 0x01, followed by
+// the same pad10*1. Domain 0x01 gives Sum256 and 0x06 gives SHA3Sum256; other
+// values give custom-tagged digests for experiments and non-standard
+// variants. Panics unless domain is in [0x01, 0x7F], as NewKeccak requires.
+func Sum256Domain(data []byte, domain byte) [32]byte {
+	if domain == 0 || domain >= 0x80 {
+		panic("keccak: Sum256Domain domain byte outside [0x01, 0x7f]")
+	}
+	s := keccakSponge{rate: rate, domain: domain}
+	s.absorb(data)
+	var out [32]byte
+	s.squeeze(out[:])
+	return out
+}
+
+// Sum256Double computes Sum256(Sum256(data)). The first digest is already
+// the leading 32 bytes of the state, so the second pass only clears the rest
+// and pads, without copying the digest out and back.
+func Sum256Double(data []byte) [32]byte {
+	var state [200]byte
+	sum256State(&state, data)
+	clear(state[32:])
+	state[32] = 0x01
+	state[rate-1] = 0x80
+	keccakF1600(&state)
+	return [32]byte(state[:32])
+}
+
+// Sum256Framed computes the Keccak-256 hash of items, each absorbed as
+// LeftEncode(len(item)) followed by the item, so ("ab") and ("a", "b") hash
+// differently. Lengths are in bytes. Unlike TupleHash256 it keeps
+// Keccak-256's padding and 32-byte output, and it does not allocate.
+func Sum256Framed(items ...[]byte) [32]byte {
+	var s sponge
+	var enc [9]byte
+	for _, item := range items {
+		s.Write(appendLeftEncode(enc[:0], uint64(len(item))))
+		s.Write(item)
+	}
+	return s.Sum256()
+}
+
+// Sum256Buffers computes the Keccak-256 hash of the concatenation of bufs,
+// absorbing each in turn across block boundaries without joining them. A
+// net.Buffers can be passed as is, so vectored I/O buffers hash in place.
+// It does not allocate.
+func Sum256Buffers(bufs [][]byte) [32]byte {
+	var s sponge
+	for _, b := range bufs {
+		s.Write(b)
+	}
+	return s.Sum256()
+}
+
+// Sum256Records splits data into recordSize-byte records and returns the
+// Keccak-256 hash of each, in order. If len(data) is not a multiple of
+// recordSize, the trailing bytes are hashed as a final, shorter record.
+// One scratch state serves every record, so the only allocation is the
+// result. Panics if recordSize <= 0.
+func Sum256Records(data []byte, recordSize int) [][32]byte {
+	if recordSize <= 0 {
+		panic("keccak: Sum256Records record size must be positive")
+	}
+	out := make([][32]byte, (len(data)+recordSize-1)/recordSize)
+	var state [200]byte
+	for i := range out {
+		state = [200]byte{}
+		out[i] = sum256State(&state, data[i*recordSize:min((i+1)*recordSize, len(data))])
+	}
+	return out
+}
+
+// Sum256Append appends the Keccak-256 hash of data to dst and returns the
+// extended slice. It does not allocate if dst has room for 32 more bytes.
+func Sum256Append(dst, data []byte) []byte {
+	d := Sum256(data)
+	return append(dst, d[:]...)
+}
+
+// Sum256Truncated returns the first n bytes of the Keccak-256 output stream
+// of data. For n <= 32 that is a prefix of Sum256(data); larger n keep
+// squeezing the sponge like Hasher.Read. Note that an Ethereum address is the
+// last 20 bytes of the digest, not the first. Panics if n < 0.
+func Sum256Truncated(data []byte, n int) []byte {
+	if n < 0 {
+		panic("keccak: negative Sum256Truncated length")
+	}
+	out := make([]byte, n)
+	if n <= 32 {
+		d := Sum256(data)
+		copy(out, d[:])
+		return out
+	}
+	var s sponge
+	s.Write(data)
+	s.Read(out)
+	return out
+}
@@ -2,20 +2,167 @@
 
 package keccak
 
-import "golang.org/x/sys/cpu"
+import (
+	"math"
+	"time"
 
-func init() { useASM = cpu.X86.HasBMI1 && cpu.X86.HasBMI2 }
+	"golang.org/x/sys/cpu"
+)
+
+// hasBMI2 reports whether the CPU supports the BMI1 and BMI2 instructions
+// (ANDN, RORX) the unrolled permutation is written with. It is computed
+// once, at package init.
+var hasBMI2 = cpu.X86.HasBMI1 && cpu.X86.HasBMI2
+
+// hasAVX2 reports whether the CPU and OS support the AVX2 instructions the
+// YMM permutation is written with. It is computed once, at package init.
+var hasAVX2 = cpu.X86.HasAVX2
+
+// hasAVX512 reports whether the CPU and OS support AVX-512F and AVX-512VL,
+// which the AVX-512 permutation uses on YMM registers. It is computed once,
+// at package init.
+var hasAVX512 = cpu.X86.HasAVX512F && cpu.X86.HasAVX512VL
+
+// The assembly permutations, as values of kernel.
+const (
+	kernelBMI2   = iota // keccakF1600BMI2, the baseline
+	kernelAVX2          // keccakF1600AVX2
+	kernelAVX512        // keccakF1600AVX512
+)
+
+// kernels lists the assembly permutations, indexed by the kernel constants,
+// with whether this CPU can run each.
+var kernels = [...]struct {
+	name string
+	run  func(a *[200]byte, buf *byte, rounds int)
+	ok   bool
+}{
+	kernelBMI2:   {"bmi2", keccakF1600BMI2, hasBMI2},
+	kernelAVX2:   {"avx2", keccakF1600AVX2, hasAVX2},
+	kernelAVX512: {"avx512", keccakF1600AVX512, hasAVX512},
+}
+
+// kernel is the permutation keccakF1600Dispatch and keccakP12 run while
+// useASM is set. init sets it to the fastest kernel the CPU supports.
+var kernel uint8 = kernelBMI2
+
+// init times the kernels the cpu.X86 flags allow and selects the fastest,
+// otherwise the generic Go permutation. The YMM kernels are not faster on
+// every CPU that has them: AVX-512 may downclock, and the BMI2 baseline
+// beats AVX2 on recent cores. verifyASM falls back to the generic
+// permutation if the selected one misbehaves.
+func init() {
+	for k := range kernels {
+		useASM = useASM || kernels[k].ok
+	}
+	if useASM {
+		kernel = selectKernel(timeKernel)
+	}
+	verifyASM()
+}
+
+// selectKernel returns the supported kernel with the lowest measure. A
+// kernel must beat the one before it in kernels by 1/16 to replace it, so
+// timing noise does not flip the choice between near-equal kernels in
+// favour of the wider one. At least one kernel must be supported.
+func selectKernel(measure func(k uint8) time.Duration) uint8 {
+	best, bestTime := -1, time.Duration(0)
+	for k := range kernels {
+		if !kernels[k].ok {
+			continue
+		}
+		if d := measure(uint8(k)); best < 0 || d < bestTime-bestTime/16 {
+			best, bestTime = k, d
+		}
+	}
+	return uint8(best)
+}
+
+// timeKernel returns the best of three timings of eight permutations by
+// kernel k, after one untimed round that warms up caches and the vector
+// units. All kernels together cost a few hundred permutations.
+func timeKernel(k uint8) time.Duration {
+	var a [200]byte
+	run := kernels[k].run
+	best := time.Duration(math.MaxInt64)
+	for i := range 4 {
+		start := time.Now()
+		for range 8 {
+			run(&a, nil, 24)
+		}
+		if d := time.Since(start); i > 0 {
+			best = min(best, d)
+		}
+	}
+	return best
+}
+
+// SetAMD64Kernel overrides the permutation init selected by timing: "bmi2",
+// "avx2" or "avx512" for an assembly kernel, or "generic" for the Go
+// permutation. It reports false, changing nothing, if name is unknown or
+// the CPU does not support that kernel. All permutations share one state
+// layout, so a Hasher may be used across a call.
+//
+// SetAMD64Kernel must not be called concurrently with hashing.
+func SetAMD64Kernel(name string) bool {
+	if name == "generic" {
+		useASM = false
+		return true
+	}
+	for k := range kernels {
+		if kernels[k].name == name && kernels[k].ok {
+			useASM, kernel = true, uint8(k)
+			return true
+		}
+	}
+	return false
+}
+
+// AMD64Kernel returns the name of the permutation in use, as accepted by
+// SetAMD64Kernel.
+func AMD64Kernel() string {
+	if !useASM {
+		return "generic"
+	}
+	return kernels[kernel].name
+}
 
 // keccakF1600BMI2 permutes state. When buf != nil, it first XORs rate bytes
-// of buf into state, saving one full memory pass.
+// of buf into state, saving one full memory pass. rounds must be 24 or 12.
 //
 //go:noescape
-func keccakF1600BMI2(a *[200]byte, buf *byte)
+func keccakF1600BMI2(a *[200]byte, buf *byte, rounds int)
 
-func keccakF1600(a *[200]byte) {
-	keccakF1600BMI2(a, nil)
-}
+// keccakF1600AVX2 is keccakF1600BMI2 with the state held in YMM registers,
+// generated by gen_keccakf_avx.go.
+//
+//go:noescape
+func keccakF1600AVX2(a *[200]byte, buf *byte, rounds int)
+
+// keccakF1600AVX512 is keccakF1600AVX2 rewritten with AVX-512VL rotates and
+// ternary logic, generated by gen_keccakf_avx.go.
+//
+//go:noescape
+func keccakF1600AVX512(a *[200]byte, buf *byte, rounds int)
+
+// keccakF1600Dispatch jumps to the kernel selected by kernel while useASM
+// is set and no SetPermutation backend is installed, and to permuteSlow
+// otherwise. Making that choice in assembly keeps keccakF1600 and
+// xorAndPermute down to one inlinable call.
+//
+//go:noescape
+func keccakF1600Dispatch(a *[200]byte, buf *byte, rounds int)
 
-func xorAndPermute(state *[200]byte, buf *byte) {
-	keccakF1600BMI2(state, buf)
+// keccakP12 applies Keccak-p[1600, 12], the last 12 rounds of Keccak-f[1600].
+func keccakP12(a *[200]byte) {
+	switch {
+	case !useASM:
+		keccakF1600GenericRounds(a, 12)
+	case kernel == kernelAVX512:
+		keccakF1600AVX512(a, nil, 12)
+	case kernel == kernelAVX2:
+		keccakF1600AVX2(a, nil, 12)
+	default:
+		keccakF1600BMI2(a, nil, 12)
+	}
 }
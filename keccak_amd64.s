@@ -0,0 +1,25 @@
+//go:build amd64 && !purego
+
+#include "go_asm.h"
+#include "textflag.h"
+
+// func keccakF1600Dispatch(a *[200]byte, buf *byte, rounds int)
+TEXT ·keccakF1600Dispatch(SB), NOSPLIT, $0-24
+	CMPB	·useASM(SB), $0
+	JEQ	slow
+	CMPQ	·permutation(SB), $0
+	JNE	slow
+	CMPB	·kernel(SB), $const_kernelAVX512
+	JEQ	avx512
+	CMPB	·kernel(SB), $const_kernelAVX2
+	JEQ	avx2
+	JMP	·keccakF1600BMI2(SB)
+
+avx2:
+	JMP	·keccakF1600AVX2(SB)
+
+avx512:
+	JMP	·keccakF1600AVX512(SB)
+
+slow:
+	JMP	·permuteSlow(SB)
@@ -0,0 +1,298 @@
+//go:build amd64 && !purego
+
+package keccak
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/sys/cpu"
+)
+
+func TestDetectKernels(t *testing.T) {
+	bmi2 := cpu.X86.HasBMI1 && cpu.X86.HasBMI2
+	avx512 := cpu.X86.HasAVX512F && cpu.X86.HasAVX512VL
+	if hasBMI2 != bmi2 || hasAVX2 != cpu.X86.HasAVX2 || hasAVX512 != avx512 {
+		t.Fatalf("hasBMI2, hasAVX2, hasAVX512 = %v, %v, %v, but the CPU feature bits say %v, %v, %v",
+			hasBMI2, hasAVX2, hasAVX512, bmi2, cpu.X86.HasAVX2, avx512)
+	}
+	if !hasBMI2 && !hasAVX2 && !hasAVX512 {
+		if useASM {
+			t.Fatal("useASM set without a supported kernel")
+		}
+		return
+	}
+	if !kernels[kernel].ok {
+		t.Fatalf("init selected %s, which the CPU does not support", kernels[kernel].name)
+	}
+	t.Logf("selected kernel: %s", AMD64Kernel())
+}
+
+// TestSelectKernel makes each supported kernel win the timing in turn and
+// checks that selectKernel picks it and that hashing through the winner
+// matches the reference.
+func TestSelectKernel(t *testing.T) {
+	if !useASM {
+		t.Skip("assembly permutation not available")
+	}
+	for w := range kernels {
+		if !kernels[w].ok {
+			continue
+		}
+		got := selectKernel(func(k uint8) time.Duration {
+			if int(k) == w {
+				return time.Microsecond
+			}
+			return time.Millisecond
+		})
+		if int(got) != w {
+			t.Errorf("selectKernel = %s, want %s", kernels[got].name, kernels[w].name)
+			continue
+		}
+		withKernel(got, func() { checkDispatch(t, kernels[got].name) })
+	}
+
+	// Near-equal timings keep the earlier kernel.
+	got := selectKernel(func(k uint8) time.Duration { return time.Microsecond - time.Duration(k) })
+	for k := range kernels {
+		if kernels[k].ok {
+			if int(got) != k {
+				t.Errorf("selectKernel with near-equal timings = %s, want %s", kernels[got].name, kernels[k].name)
+			}
+			break
+		}
+	}
+}
+
+func TestSetAMD64Kernel(t *testing.T) {
+	defer func(prevASM bool, prev uint8) { useASM, kernel = prevASM, prev }(useASM, kernel)
+	data := []byte("SetAMD64Kernel")
+	want := refSum256(data)
+	for _, name := range []string{"generic", "bmi2", "avx2", "avx512"} {
+		ok := SetAMD64Kernel(name)
+		supported := name == "generic"
+		for k := range kernels {
+			supported = supported || kernels[k].name == name && kernels[k].ok
+		}
+		if ok != supported {
+			t.Errorf("SetAMD64Kernel(%q) = %v, want %v", name, ok, supported)
+		}
+		if ok && AMD64Kernel() != name {
+			t.Errorf("after SetAMD64Kernel(%q), AMD64Kernel() = %q", name, AMD64Kernel())
+		}
+		if got := Sum256(data); got != want {
+			t.Errorf("after SetAMD64Kernel(%q): Sum256 = %x, want %x", name, got, want)
+		}
+	}
+	prev := AMD64Kernel()
+	if SetAMD64Kernel("sse") || AMD64Kernel() != prev {
+		t.Errorf("SetAMD64Kernel(%q) changed the kernel", "sse")
+	}
+}
+
+// withKernel runs f with keccakF1600Dispatch forced to kernel k. It must
+// not be used from parallel tests.
+func withKernel(k uint8, f func()) {
+	defer func(prev uint8) { kernel = prev }(kernel)
+	kernel = k
+	f()
+}
+
+// TestDispatchKernels forces each kernel the CPU supports, the BMI2
+// baseline first, through the dispatcher and checks it against the
+// reference.
+func TestDispatchKernels(t *testing.T) {
+	if !useASM {
+		t.Skip("assembly permutation not available")
+	}
+	for k := range kernels {
+		if !kernels[k].ok {
+			continue
+		}
+		withKernel(uint8(k), func() { checkDispatch(t, kernels[k].name) })
+	}
+}
+
+// checkDispatch checks Sum256, keccakF1600 and keccakP12 through the
+// dispatcher against the reference.
+func checkDispatch(t *testing.T, name string) {
+	t.Helper()
+	data := make([]byte, 3*rate+7)
+	for i := range data {
+		data[i] = byte(i * 5)
+	}
+	if got, want := Sum256(data), refSum256(data); got != want {
+		t.Errorf("%s: Sum256 = %x, want %x", name, got, want)
+	}
+	var a [200]byte
+	for i := range a {
+		a[i] = byte(i)
+	}
+	want := a
+	keccakF1600(&a)
+	keccakF1600Generic(&want)
+	keccakP12(&a)
+	keccakF1600GenericRounds(&want, 12)
+	if a != want {
+		t.Errorf("%s: keccakF1600 then keccakP12 mismatch:\ngot:  %x\nwant: %x", name, a, want)
+	}
+}
+
+// checkKernel runs kernel, whatever init selected, against the generic
+// permutation: three bare permutations, one with a block XORed in and one
+// of 12 rounds.
+func checkKernel(t *testing.T, name string, kernel func(a *[200]byte, buf *byte, rounds int)) {
+	var a [200]byte
+	for i := range a {
+		a[i] = byte(i*13 + 5)
+	}
+	want := a
+	for range 3 {
+		kernel(&a, nil, 24)
+		keccakF1600Generic(&want)
+		if a != want {
+			t.Fatalf("%s mismatch:\ngot:  %x\nwant: %x", name, a, want)
+		}
+	}
+
+	var buf [rate]byte
+	for i := range buf {
+		buf[i] = byte(i ^ 0xa5)
+	}
+	kernel(&a, &buf[0], 24)
+	xorIn(&want, buf[:])
+	keccakF1600Generic(&want)
+	if a != want {
+		t.Fatalf("%s with block mismatch:\ngot:  %x\nwant: %x", name, a, want)
+	}
+
+	kernel(&a, nil, 12)
+	keccakF1600GenericRounds(&want, 12)
+	if a != want {
+		t.Fatalf("%s 12 rounds mismatch:\ngot:  %x\nwant: %x", name, a, want)
+	}
+}
+
+func TestBaselinePermutation(t *testing.T) {
+	if !hasBMI2 {
+		t.Skip("BMI2 not available")
+	}
+	checkKernel(t, "keccakF1600BMI2", keccakF1600BMI2)
+}
+
+func TestAVX2Permutation(t *testing.T) {
+	if !hasAVX2 {
+		t.Skip("AVX2 not available")
+	}
+	checkKernel(t, "keccakF1600AVX2", keccakF1600AVX2)
+}
+
+func TestAVX512Permutation(t *testing.T) {
+	if !hasAVX512 {
+		t.Skip("AVX-512 not available")
+	}
+	checkKernel(t, "keccakF1600AVX512", keccakF1600AVX512)
+}
+
+// FuzzKernelsMatchBMI2 checks the AVX2 and AVX-512 permutations against
+// the scalar BMI2 one on arbitrary states and blocks, for both round counts
+// and with and without the fused absorb.
+func FuzzKernelsMatchBMI2(f *testing.F) {
+	f.Add(make([]byte, 200), make([]byte, rate))
+	f.Add([]byte("state"), []byte("block"))
+	f.Fuzz(func(t *testing.T, seed, block []byte) {
+		if !hasBMI2 {
+			t.Skip("BMI2 not available")
+		}
+		var a [200]byte
+		copy(a[:], seed)
+		var buf [rate]byte
+		copy(buf[:], block)
+		for _, k := range kernels[kernelAVX2:] {
+			if !k.ok {
+				continue
+			}
+			for _, rounds := range []int{24, 12} {
+				for _, in := range []*byte{nil, &buf[0]} {
+					got, want := a, a
+					k.run(&got, in, rounds)
+					keccakF1600BMI2(&want, in, rounds)
+					if got != want {
+						t.Fatalf("%s, %d rounds, block %v: mismatch for state %x, block %x", k.name, rounds, in != nil, seed, block)
+					}
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkKeccakF1600Kernels times each amd64 permutation this CPU can run.
+func BenchmarkKeccakF1600Kernels(b *testing.B) {
+	var a [200]byte
+	b.Run("generic", func(b *testing.B) {
+		b.SetBytes(rate)
+		for b.Loop() {
+			keccakF1600Generic(&a)
+		}
+	})
+	for _, k := range kernels {
+		b.Run(k.name, func(b *testing.B) {
+			if !k.ok {
+				b.Skip("kernel not available")
+			}
+			b.SetBytes(rate)
+			for b.Loop() {
+				k.run(&a, nil, 24)
+			}
+		})
+	}
+}
+
+// BenchmarkSum256Kernels hashes the large inputs, where the permutation
+// dominates, with each assembly kernel in turn.
+func BenchmarkSum256Kernels(b *testing.B) {
+	for _, size := range []int{4096, 500 * 1024} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		for k := range kernels {
+			b.Run(benchName(size)+"/"+kernels[k].name, func(b *testing.B) {
+				if !kernels[k].ok || !useASM {
+					b.Skip("kernel not available")
+				}
+				b.SetBytes(int64(size))
+				b.ReportAllocs()
+				withKernel(uint8(k), func() {
+					for b.Loop() {
+						Sum256(data)
+					}
+				})
+			})
+		}
+	}
+}
+
+// BenchmarkAbsorbBlock compares the fused absorb, where keccakF1600BMI2
+// XORs the block in while loading the state, against xorIn followed by a
+// bare permutation.
+func BenchmarkAbsorbBlock(b *testing.B) {
+	if !hasBMI2 {
+		b.Skip("BMI2 not available")
+	}
+	var a [200]byte
+	var block [rate]byte
+	b.Run("fused", func(b *testing.B) {
+		b.SetBytes(rate)
+		for b.Loop() {
+			keccakF1600BMI2(&a, &block[0], 24)
+		}
+	})
+	b.Run("split", func(b *testing.B) {
+		b.SetBytes(rate)
+		for b.Loop() {
+			xorIn(&a, block[:])
+			keccakF1600BMI2(&a, nil, 24)
+		}
+	})
+}
@@ -8,23 +8,92 @@ import (
 	"golang.org/x/sys/cpu"
 )
 
-// Apple Silicon always has Armv8.2-A SHA3 extensions (VEOR3, VRAX1, VXAR, VBCAX).
-// On other ARM64 platforms, detect at runtime via CPU feature flags.
-// When SHA3 is unavailable, falls back to x/crypto/sha3.
+// hasSHA3 reports whether the CPU supports the Armv8.2-A SHA3 extensions
+// (VEOR3, VRAX1, VXAR, VBCAX). It is computed once, at package init.
+var hasSHA3 = detectSHA3()
+
+// detectSHA3 reports whether this machine's CPU has SHA3.
+func detectSHA3() bool {
+	return sha3Supported(runtime.GOOS, cpu.Initialized, cpu.ARM64.HasSHA3)
+}
+
+// sha3Supported trusts the CPU feature bit wherever x/sys/cpu can read it
+// (Linux, Windows and the BSDs, e.g. on Graviton, Ampere or Snapdragon X).
+// FreeBSD exposes the ID_AA64ISAR0_EL1 register to user space, and NetBSD
+// and OpenBSD report it through sysctl. Windows reports it through
+// IsProcessorFeaturePresent without setting cpu.Initialized, so a clear bit
+// is final there too. Only when the bits are unreadable does it fall back to
+// the OS: every Apple Silicon core running darwin or ios has SHA3.
+func sha3Supported(goos string, initialized, hasSHA3 bool) bool {
+	if hasSHA3 {
+		return true
+	}
+	if initialized || goos == "windows" {
+		return false
+	}
+	return goos == "darwin" || goos == "ios"
+}
+
+// When SHA3 is unavailable, or fails verifyASM, falls back to the generic
+// permutation; a failed check also keeps SetUseSHA3 from re-enabling it.
 func init() {
-	useASM = runtime.GOOS == "darwin" || runtime.GOOS == "ios" || cpu.ARM64.HasSHA3
+	useASM = hasSHA3
+	if !verifyASM() {
+		hasSHA3 = false
+	}
+}
+
+// SetUseSHA3 enables or disables the NEON SHA3 permutation. Disabling it
+// selects the generic Go permutation, which is useful for benchmarking and on
+// machines where the extensions regress. Enabling it has no effect when the
+// CPU lacks SHA3. Both permutations share one state layout, so a Hasher may
+// be used across a call.
+//
+// SetUseSHA3 must not be called concurrently with hashing.
+func SetUseSHA3(enable bool) { useASM = enable && hasSHA3 }
+
+// sha3Threshold is the input length below which Sum256 uses the generic
+// permutation; see SetSHA3Threshold.
+var sha3Threshold int
+
+// SetSHA3Threshold makes Sum256 hash inputs shorter than n bytes with the
+// generic Go permutation and longer ones with NEON SHA3, choosing per call.
+// The NEON path has a fixed cost per permutation that can outweigh its
+// speedup on the shortest inputs on some cores; BenchmarkSHA3Threshold sweeps
+// both paths across sizes to find the crossover for a machine. The default,
+// 0, always uses NEON. The threshold has no effect while SHA3 is disabled or
+// a SetPermutation backend is installed, and a streaming Hasher, which does
+// not know its input length up front, always uses NEON.
+//
+// SetSHA3Threshold must not be called concurrently with hashing.
+func SetSHA3Threshold(n int) { sha3Threshold = n }
+
+// genericBelowThreshold reports whether Sum256 should hash n bytes with the
+// generic permutation.
+func genericBelowThreshold(n int) bool {
+	return n < sha3Threshold && useASM && permutation == nil
 }
 
 // keccakF1600Sha3 permutes state. When buf != nil, it first XORs rate bytes
-// of buf into state, saving one full memory pass.
+// of buf into state, saving one full memory pass. It runs the last rounds
+// rounds of Keccak-f[1600].
 //
 //go:noescape
-func keccakF1600Sha3(a *[200]byte, buf *byte)
+func keccakF1600Sha3(a *[200]byte, buf *byte, rounds int)
 
-func keccakF1600(a *[200]byte) {
-	keccakF1600Sha3(a, nil)
-}
+// keccakF1600Dispatch jumps to keccakF1600Sha3 while useASM is set and no
+// SetPermutation backend is installed, and to permuteSlow otherwise. Making
+// that choice in assembly keeps keccakF1600 and xorAndPermute down to one
+// inlinable call.
+//
+//go:noescape
+func keccakF1600Dispatch(a *[200]byte, buf *byte, rounds int)
 
-func xorAndPermute(state *[200]byte, buf *byte) {
-	keccakF1600Sha3(state, buf)
+// keccakP12 applies Keccak-p[1600, 12], the last 12 rounds of Keccak-f[1600].
+func keccakP12(a *[200]byte) {
+	if !useASM {
+		keccakF1600GenericRounds(a, 12)
+		return
+	}
+	keccakF1600Sha3(a, nil, 12)
 }
@@ -0,0 +1,14 @@
+//go:build arm64 && !purego
+
+#include "textflag.h"
+
+// func keccakF1600Dispatch(a *[200]byte, buf *byte, rounds int)
+TEXT ·keccakF1600Dispatch(SB), NOFRAME|NOSPLIT, $0-24
+	MOVBU	·useASM(SB), R0
+	CBZ	R0, slow
+	MOVD	·permutation(SB), R0
+	CBNZ	R0, slow
+	B	·keccakF1600Sha3(SB)
+
+slow:
+	B	·permuteSlow(SB)
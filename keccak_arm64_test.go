@@ -0,0 +1,130 @@
+//go:build arm64 && !purego
+
+package keccak
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+	"golang.org/x/sys/cpu"
+)
+
+func TestSetUseSHA3(t *testing.T) {
+	defer SetUseSHA3(true)
+
+	data := make([]byte, 3*rate+17)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	SetUseSHA3(true)
+	withSHA3 := Sum256(data)
+	var h Hasher
+	h.Write(data)
+	withSHA3H := h.Sum256()
+
+	SetUseSHA3(false)
+	if useASM {
+		t.Fatal("SetUseSHA3(false) left the SHA3 path enabled")
+	}
+	without := Sum256(data)
+	var h2 Hasher
+	h2.Write(data)
+	withoutH := h2.Sum256()
+
+	if withSHA3 != without || withSHA3H != withoutH || withSHA3 != withSHA3H {
+		t.Fatalf("SHA3 and fallback paths disagree: %x %x %x %x", withSHA3, without, withSHA3H, withoutH)
+	}
+}
+
+func TestDetectSHA3(t *testing.T) {
+	if (cpu.Initialized || runtime.GOOS == "windows") && hasSHA3 != cpu.ARM64.HasSHA3 {
+		t.Fatalf("hasSHA3 = %v, but the CPU feature bit says %v", hasSHA3, cpu.ARM64.HasSHA3)
+	}
+	t.Logf("SHA3 extensions detected: %v", hasSHA3)
+
+	// Whatever path detection picked must agree with x/crypto.
+	for _, n := range []int{0, 31, rate, 5*rate + 9} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i ^ 0x5c)
+		}
+		want := sha3.NewLegacyKeccak256()
+		want.Write(data)
+		if got := Sum256(data); string(got[:]) != string(want.Sum(nil)) {
+			t.Errorf("len=%d: Sum256 = %x, want %x", n, got, want.Sum(nil))
+		}
+		var generic [32]byte
+		withGeneric(func() { generic = Sum256(data) })
+		if got := Sum256(data); got != generic {
+			t.Errorf("len=%d: Sum256 = %x, generic path %x", n, got, generic)
+		}
+	}
+}
+
+func TestSHA3Supported(t *testing.T) {
+	for _, tc := range []struct {
+		goos        string
+		initialized bool
+		hasSHA3     bool
+		want        bool
+	}{
+		{"linux", true, true, true},
+		{"linux", true, false, false},
+		{"freebsd", true, true, true},
+		{"freebsd", true, false, false},
+		{"netbsd", true, true, true},
+		{"netbsd", false, false, false}, // unreadable ID registers: generic
+		{"openbsd", true, false, false},
+		{"windows", false, true, true},
+		{"windows", false, false, false},
+		{"darwin", false, false, true},
+		{"darwin", true, false, false},
+		{"ios", false, false, true},
+		{"plan9", false, false, false},
+	} {
+		if got := sha3Supported(tc.goos, tc.initialized, tc.hasSHA3); got != tc.want {
+			t.Errorf("sha3Supported(%q, %v, %v) = %v, want %v", tc.goos, tc.initialized, tc.hasSHA3, got, tc.want)
+		}
+	}
+}
+
+func TestSHA3Threshold(t *testing.T) {
+	defer SetSHA3Threshold(0)
+	data := make([]byte, 3*rate+1)
+	for i := range data {
+		data[i] = byte(i*11 + 5)
+	}
+	for _, threshold := range []int{0, 1, 32, 33, rate, rate + 1, len(data) + 1} {
+		SetSHA3Threshold(threshold)
+		for _, n := range []int{0, 1, 32, 33, rate - 1, rate, rate + 1, len(data)} {
+			if got, want := Sum256(data[:n]), refSum256(data[:n]); got != want {
+				t.Errorf("threshold=%d len=%d: got %x, want %x", threshold, n, got, want)
+			}
+		}
+	}
+}
+
+// BenchmarkSHA3Threshold hashes each size with NEON SHA3 and with the generic
+// permutation; the smallest size where sha3 wins is a machine's threshold.
+func BenchmarkSHA3Threshold(b *testing.B) {
+	defer SetSHA3Threshold(0)
+	for _, size := range []int{1, 8, 32, 64, 100, 135, 136, 200, 272, 1024} {
+		data := make([]byte, size)
+		for _, path := range []struct {
+			name      string
+			threshold int
+		}{{"sha3", 0}, {"generic", size + 1}} {
+			b.Run(fmt.Sprintf("%s/%d", path.name, size), func(b *testing.B) {
+				SetSHA3Threshold(path.threshold)
+				b.SetBytes(int64(size))
+				b.ReportAllocs()
+				for b.Loop() {
+					Sum256(data)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,146 @@
+//go:build (amd64 || arm64 || s390x) && !purego
+
+package keccak
+
+import "testing"
+
+func TestPermutationMatchesGeneric(t *testing.T) {
+	if !useASM {
+		t.Skip("assembly permutation not available")
+	}
+	var a, b [200]byte
+	for i := range a {
+		a[i] = byte(i*7 + 1)
+	}
+	b = a
+	for range 3 {
+		keccakF1600(&a)
+		keccakF1600Generic(&b)
+		if a != b {
+			t.Fatalf("keccakF1600 mismatch:\ngot:  %x\nwant: %x", a, b)
+		}
+	}
+
+	var buf [rate]byte
+	for i := range buf {
+		buf[i] = byte(255 - i)
+	}
+	xorAndPermute(&a, &buf[0])
+	xorIn(&b, buf[:])
+	keccakF1600Generic(&b)
+	if a != b {
+		t.Fatalf("xorAndPermute mismatch:\ngot:  %x\nwant: %x", a, b)
+	}
+}
+
+func FuzzPermutationMatchesGeneric(f *testing.F) {
+	f.Add(make([]byte, 200))
+	f.Add([]byte("abc"))
+	f.Fuzz(func(t *testing.T, seed []byte) {
+		if !useASM {
+			t.Skip("assembly permutation not available")
+		}
+		var a [200]byte
+		copy(a[:], seed)
+		b := a
+		keccakF1600(&a)
+		keccakF1600Generic(&b)
+		if a != b {
+			t.Fatalf("keccakF1600 mismatch for state %x", seed)
+		}
+		keccakP12(&a)
+		keccakF1600GenericRounds(&b, 12)
+		if a != b {
+			t.Fatalf("keccakP12 mismatch for state %x", seed)
+		}
+	})
+}
+
+// FuzzXorAndPermuteMatchesGeneric checks the absorb fused into the assembly
+// (the 128-bit loads and XORs of the NEON path, for instance) against the
+// scalar xorIn followed by the generic permutation.
+func FuzzXorAndPermuteMatchesGeneric(f *testing.F) {
+	f.Add(make([]byte, 200), make([]byte, rate))
+	f.Add([]byte("state"), []byte("block"))
+	f.Fuzz(func(t *testing.T, seed, block []byte) {
+		if !useASM {
+			t.Skip("assembly permutation not available")
+		}
+		var a [200]byte
+		copy(a[:], seed)
+		var buf [rate]byte
+		copy(buf[:], block)
+		b := a
+		xorAndPermute(&a, &buf[0])
+		xorIn(&b, buf[:])
+		keccakF1600Generic(&b)
+		if a != b {
+			t.Fatalf("xorAndPermute mismatch for state %x, block %x", seed, block)
+		}
+	})
+}
+
+func TestSum256GenericFallback(t *testing.T) {
+	data := make([]byte, 5*rate+3)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	native := Sum256(data)
+	var h Hasher
+	h.Write(data[:rate+1])
+
+	withGeneric(func() {
+		if got := Sum256(data); got != native {
+			t.Fatalf("generic Sum256 = %x, want %x", got, native)
+		}
+		// The state layout is shared, so a Hasher survives the switch.
+		h.Write(data[rate+1:])
+		if got := h.Sum256(); got != native {
+			t.Fatalf("Hasher across the switch = %x, want %x", got, native)
+		}
+	})
+}
+
+// BenchmarkKeccakF1600 measures the permutation this build selected, the
+// baseline any new kernel for the platform has to beat.
+func BenchmarkKeccakF1600(b *testing.B) {
+	if !useASM {
+		b.Skip("assembly permutation not available")
+	}
+	var a [200]byte
+	b.SetBytes(rate)
+	b.ReportAllocs()
+	for b.Loop() {
+		keccakF1600(&a)
+	}
+}
+
+// FuzzSum256AsmMatchesGeneric pits the accelerated paths against this
+// package's own generic permutation, so a mismatch points at the assembly
+// rather than at the reference library.
+func FuzzSum256AsmMatchesGeneric(f *testing.F) {
+	f.Add([]byte(""), uint16(0))
+	f.Add(make([]byte, rate), uint16(1))
+	f.Add(make([]byte, 3*rate+5), uint16(rate+1))
+	f.Fuzz(func(t *testing.T, data []byte, split uint16) {
+		if !useASM {
+			t.Skip("assembly permutation not available")
+		}
+		cut := int(split) % (len(data) + 1)
+		sum := func() (one, streamed [32]byte) {
+			var h Hasher
+			h.Write(data[:cut])
+			h.Write(data[cut:])
+			return Sum256(data), h.Sum256()
+		}
+		asmSum, asmStreamed := sum()
+		var genSum, genStreamed [32]byte
+		withGeneric(func() { genSum, genStreamed = sum() })
+		if asmSum != genSum {
+			t.Fatalf("Sum256 mismatch for %x:\nasm:     %x\ngeneric: %x", data, asmSum, genSum)
+		}
+		if asmStreamed != genStreamed {
+			t.Fatalf("Hasher mismatch for %x split at %d:\nasm:     %x\ngeneric: %x", data, cut, asmStreamed, genStreamed)
+		}
+	})
+}
@@ -0,0 +1,22 @@
+//go:build (!arm64 && !amd64 && !s390x) || purego
+
+package keccak
+
+import "testing"
+
+// TestGenericBuild runs on every architecture without assembly, including
+// big-endian ones such as mips64, where lane conversion mistakes would show.
+func TestGenericBuild(t *testing.T) {
+	if useASM {
+		t.Fatal("useASM is set in a build without assembly")
+	}
+	for n := range 2*rate + 3 {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(n ^ i*7)
+		}
+		if got, want := Sum256(data), refSum256(data); got != want {
+			t.Fatalf("len=%d: Sum256 = %x, reference %x", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !amd64 || purego
+
+package keccak
+
+// SetAMD64Kernel selects the amd64 assembly permutation by name.
+// It has no effect and returns false on other architectures or with the
+// purego build tag.
+func SetAMD64Kernel(name string) bool { return false }
+
+// AMD64Kernel returns the name of the amd64 permutation in use.
+// It returns "" on other architectures or with the purego build tag.
+func AMD64Kernel() string { return "" }
@@ -0,0 +1,14 @@
+//go:build !arm64 || purego
+
+package keccak
+
+// SetUseSHA3 enables or disables the arm64 NEON SHA3 permutation.
+// It has no effect on other architectures or with the purego build tag.
+func SetUseSHA3(enable bool) {}
+
+// SetSHA3Threshold sets the input length below which arm64 Sum256 uses the
+// generic permutation instead of NEON SHA3.
+// It has no effect on other architectures or with the purego build tag.
+func SetSHA3Threshold(n int) {}
+
+func genericBelowThreshold(n int) bool { return false }
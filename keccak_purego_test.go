@@ -0,0 +1,30 @@
+//go:build purego
+
+package keccak
+
+import "testing"
+
+// TestPuregoBuild asserts that -tags purego is an assembly-free build on
+// every architecture: no assembly file is compiled (the .s files carry
+// !purego), nothing can switch an accelerated path on, and every
+// permutation entry point is the generic one.
+func TestPuregoBuild(t *testing.T) {
+	SetUseSHA3(true)
+	if useASM || !verifyASM() {
+		t.Fatal("accelerated permutation enabled in a purego build")
+	}
+	var a, b [200]byte
+	for i := range a {
+		a[i] = byte(i * 3)
+	}
+	b = a
+	keccakF1600(&a)
+	keccakF1600Generic(&b)
+	var c [200]byte
+	keccakP12(&c)
+	var d [200]byte
+	keccakF1600GenericRounds(&d, 12)
+	if a != b || c != d {
+		t.Fatal("permutation differs from the generic one in a purego build")
+	}
+}
@@ -0,0 +1,34 @@
+//go:build s390x && !purego
+
+package keccak
+
+import "golang.org/x/sys/cpu"
+
+// CPACF provides SHA-3 in hardware via KIMD. With the SHA3-256 function code
+// (rate 136), KIMD XORs each full block into the state and applies
+// Keccak-f[1600], which is exactly xorAndPermute. Padding is done in Go, so the
+// Keccak-256 domain byte works the same as on other platforms.
+func init() {
+	useASM = cpu.S390X.HasSHA3
+	verifyASM()
+}
+
+// kimdSHA3_256 is the KIMD function code for SHA3-256.
+const kimdSHA3_256 = 33
+
+// zeroBlock turns KIMD into a bare permutation.
+var zeroBlock [rate]byte
+
+// keccakF1600Dispatch runs KIMD over buf, or zeroBlock when buf is nil,
+// while useASM is set and no SetPermutation backend is installed, and jumps
+// to permuteSlow otherwise. Making that choice in assembly keeps keccakF1600
+// and xorAndPermute down to one inlinable call. rounds must be 24.
+//
+//go:noescape
+func keccakF1600Dispatch(a *[200]byte, buf *byte, rounds int)
+
+// keccakP12 applies Keccak-p[1600, 12], the last 12 rounds of Keccak-f[1600].
+// KIMD only implements the full permutation, so this is always generic.
+func keccakP12(a *[200]byte) {
+	keccakF1600GenericRounds(a, 12)
+}
@@ -0,0 +1,27 @@
+//go:build !purego
+
+#include "go_asm.h"
+#include "textflag.h"
+
+// func keccakF1600Dispatch(a *[200]byte, buf *byte, rounds int)
+TEXT ·keccakF1600Dispatch(SB), NOFRAME|NOSPLIT, $0-24
+	MOVBZ	·useASM(SB), R0
+	CMPBEQ	R0, $0, slow
+	MOVD	·permutation(SB), R0
+	CMPBNE	R0, $0, slow
+
+	MOVD	$const_kimdSHA3_256, R0
+	MOVD	a+0(FP), R1
+	MOVD	buf+8(FP), R2
+	MOVD	$const_rate, R3
+	CMPBNE	R2, $0, continue
+	MOVD	$·zeroBlock(SB), R2
+
+continue:
+	KIMD	R0, R2 // compute intermediate message digest
+	BVS	continue // continue if interrupted
+	MOVD	$0, R0
+	RET
+
+slow:
+	BR	·permuteSlow(SB)
@@ -2,8 +2,16 @@ package keccak
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"encoding"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"hash"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
 	"testing"
 
 	"golang.org/x/crypto/sha3"
@@ -140,6 +148,43 @@ func TestReadMultipleCalls(t *testing.T) {
 	}
 }
 
+func TestReadLengths(t *testing.T) {
+	data := []byte("squeeze boundaries")
+	for _, n := range []int{0, 1, 135, 136, 137, 1000} {
+		ref := sha3.NewLegacyKeccak256().(KeccakState)
+		ref.Write(data)
+		want := make([]byte, n+rate)
+		ref.Read(want)
+
+		// One Read of n bytes, then one more block, must continue the stream.
+		var h Hasher
+		h.Write(data)
+		got := make([]byte, n+rate)
+		if m, err := h.Read(got[:n]); m != n || err != nil {
+			t.Fatalf("n=%d: Read = %d, %v", n, m, err)
+		}
+		h.Read(got[n:])
+		if !bytes.Equal(got, want) {
+			t.Errorf("n=%d: Read then Read(%d) = %x, want %x", n, rate, got, want)
+		}
+	}
+}
+
+func TestReadZeroLength(t *testing.T) {
+	for _, out := range [][]byte{nil, make([]byte, 0)} {
+		var h Hasher
+		h.Write([]byte("ab"))
+		if n, err := h.Read(out); n != 0 || err != nil {
+			t.Fatalf("Read(%#v) = %d, %v; want 0, nil", out, n, err)
+		}
+		// Nothing was padded or permuted, so absorbing continues.
+		h.Write([]byte("c"))
+		if got, want := h.Sum256(), Sum256([]byte("abc")); got != want {
+			t.Fatalf("after empty Read: Sum256 = %x, want %x", got, want)
+		}
+	}
+}
+
 func TestReadEmpty(t *testing.T) {
 	// Read from hasher with no data written.
 	ref := sha3.NewLegacyKeccak256()
@@ -171,6 +216,57 @@ func TestReadAfterReset(t *testing.T) {
 	}
 }
 
+func TestHasherOutputModel(t *testing.T) {
+	data := []byte("one squeeze phase")
+	ref := sha3.NewLegacyKeccak256().(KeccakState)
+	ref.Write(data)
+	stream := make([]byte, 3*rate)
+	ref.Read(stream)
+
+	// Snapshots while absorbing do not finalize.
+	var h Hasher
+	h.Write(data)
+	if d := h.Sum256(); !bytes.Equal(d[:], stream[:32]) || !bytes.Equal(h.Sum(nil), stream[:32]) {
+		t.Fatalf("Sum256 = %x, want %x", d, stream[:32])
+	}
+	out := make([]byte, 2*rate)
+	h.Read(out[:40])
+	h.Read(out[40:])
+	if !bytes.Equal(out, stream[:2*rate]) {
+		t.Fatalf("Read after Sum256 = %x, want %x", out, stream[:2*rate])
+	}
+
+	// SumInPlace enters squeezing, and Read continues after the digest.
+	h.Reset()
+	h.Write(data)
+	if d := h.SumInPlace(); !bytes.Equal(d[:], stream[:32]) {
+		t.Fatalf("SumInPlace = %x, want %x", d, stream[:32])
+	}
+	h.Read(out)
+	if !bytes.Equal(out, stream[32:32+2*rate]) {
+		t.Fatalf("Read after SumInPlace = %x, want %x", out, stream[32:32+2*rate])
+	}
+
+	for name, f := range map[string]func(h *Hasher){
+		"Write":      func(h *Hasher) { h.Write(data) },
+		"Sum256":     func(h *Hasher) { h.Sum256() },
+		"Sum":        func(h *Hasher) { h.Sum(nil) },
+		"Snapshot":   func(h *Hasher) { h.Snapshot() },
+		"SumInPlace": func(h *Hasher) { h.SumInPlace() },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s while squeezing did not panic", name)
+				}
+			}()
+			var h Hasher
+			h.Read(make([]byte, 1))
+			f(&h)
+		}()
+	}
+}
+
 func TestWriteAfterReadPanics(t *testing.T) {
 	defer func() {
 		if r := recover(); r == nil {
@@ -183,6 +279,588 @@ func TestWriteAfterReadPanics(t *testing.T) {
 	h.Write([]byte("more")) // should panic
 }
 
+func TestSumInPlace(t *testing.T) {
+	for _, n := range []int{0, 1, 135, 136, 137, 500} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		var h Hasher
+		h.Write(data)
+		if got, want := h.SumInPlace(), Sum256(data); got != want {
+			t.Errorf("len=%d: SumInPlace = %x, want %x", n, got, want)
+		}
+		h.Reset()
+		h.Write(data)
+		if got, want := h.SumInPlace(), Sum256(data); got != want {
+			t.Errorf("len=%d: SumInPlace after Reset = %x, want %x", n, got, want)
+		}
+	}
+}
+
+func TestSum256Reset(t *testing.T) {
+	var h Hasher
+	for n := range 300 {
+		msg := make([]byte, n)
+		for i := range msg {
+			msg[i] = byte(n + i)
+		}
+		h.Write(msg)
+		if got, want := h.Sum256Reset(), Sum256(msg); got != want {
+			t.Fatalf("message %d: Sum256Reset = %x, want %x", n, got, want)
+		}
+	}
+	if got, want := h.Sum256(), Sum256(nil); got != want {
+		t.Fatalf("hasher not reset: Sum256 = %x, want %x", got, want)
+	}
+}
+
+func TestWriteAfterSumInPlacePanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic on Write after SumInPlace")
+		}
+	}()
+	var h Hasher
+	h.Write([]byte("data"))
+	h.SumInPlace()
+	h.Write([]byte("more")) // should panic
+}
+
+func TestResetClearsHasher(t *testing.T) {
+	var h Hasher
+	h.Write(make([]byte, rate+17))
+	h.Write([]byte("secret"))
+	h.Reset()
+	if h != (Hasher{}) {
+		t.Fatal("Hasher differs from its zero value after Reset")
+	}
+
+	h.Write([]byte("secret"))
+	h.Read(make([]byte, 8))
+	h.Reset()
+	if h != (Hasher{}) {
+		t.Fatal("Hasher differs from its zero value after Read and Reset")
+	}
+
+	// A short message never permutes, so Reset only clears the buffer; that
+	// must include the padding Sum256 wrote past the input.
+	h.Write([]byte("secret"))
+	h.Sum256()
+	h.Reset()
+	if h != (Hasher{}) {
+		t.Fatal("Hasher differs from its zero value after Sum256 and Reset")
+	}
+
+	h.Write([]byte("secret"))
+	h.Sum256Reset()
+	if h != (Hasher{}) {
+		t.Fatal("Hasher differs from its zero value after Sum256Reset")
+	}
+}
+
+func TestHasherSum(t *testing.T) {
+	data := make([]byte, 3*rate+5)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	var h Hasher
+	for _, n := range []int{0, 1, rate - 1, rate, 2*rate + 9, len(data)} {
+		h.Reset()
+		h.Write(data[:n])
+		want := Sum256(data[:n])
+
+		prefix := []byte("prefix")
+		got := h.Sum(prefix)
+		if !bytes.Equal(got[:len(prefix)], []byte("prefix")) || !bytes.Equal(got[len(prefix):], want[:]) {
+			t.Fatalf("len=%d: Sum(prefix) = %x, want prefix || %x", n, got, want)
+		}
+		if again := h.Sum(nil); !bytes.Equal(again, want[:]) {
+			t.Fatalf("len=%d: second Sum = %x, want %x", n, again, want)
+		}
+		if d := h.Sum256(); d != want {
+			t.Fatalf("len=%d: Sum256 after Sum = %x, want %x", n, d, want)
+		}
+	}
+
+	// Sum leaves the hasher writable.
+	h.Reset()
+	h.Write(data[:10])
+	h.Sum(nil)
+	h.Write(data[10:])
+	if got, want := h.Sum(nil), Sum256(data); !bytes.Equal(got, want[:]) {
+		t.Fatalf("Write after Sum = %x, want %x", got, want)
+	}
+}
+
+func TestHasherAsHashHash(t *testing.T) {
+	key, msg := []byte("key"), []byte("The quick brown fox jumps over the lazy dog")
+	mac := hmac.New(func() hash.Hash { return NewFastKeccak() }, key)
+	mac.Write(msg)
+	if got, want := mac.Sum(nil), HMAC256(key, msg); !bytes.Equal(got, want[:]) {
+		t.Fatalf("crypto/hmac over Hasher = %x, want %x", got, want)
+	}
+}
+
+func TestHasherWriteAligned(t *testing.T) {
+	data := make([]byte, 7*rate+50)
+	for i := range data {
+		data[i] = byte(i * 13)
+	}
+	want := Sum256(data)
+	for _, blocks := range []int{0, 1, 3, 7} {
+		var h Hasher
+		h.WriteAligned(data[:blocks*rate])
+		h.Write(data[blocks*rate:])
+		if got := h.Sum256(); got != want {
+			t.Errorf("blocks=%d: WriteAligned then Write = %x, want %x", blocks, got, want)
+		}
+	}
+
+	var h Hasher
+	h.WriteAligned(data[:rate])
+	h.WriteAligned(data[rate : 4*rate])
+	h.Write(data[4*rate:])
+	if got := h.Sum256(); got != want {
+		t.Errorf("two WriteAligned calls then Write = %x, want %x", got, want)
+	}
+}
+
+func TestHasherPendingAvailable(t *testing.T) {
+	var h Hasher
+	if h.Pending() != 0 || h.Available() != rate {
+		t.Fatalf("zero Hasher: Pending, Available = %d, %d; want 0, %d", h.Pending(), h.Available(), rate)
+	}
+	written := 0
+	for _, n := range []int{1, 10, rate - 11, 0, 5, 2 * rate, rate - 5, 3*rate + 7} {
+		h.Write(make([]byte, n))
+		written += n
+		if want := written % rate; h.Pending() != want || h.Available() != rate-want {
+			t.Fatalf("after %d bytes: Pending, Available = %d, %d; want %d, %d",
+				written, h.Pending(), h.Available(), want, rate-want)
+		}
+	}
+	h.Reset()
+	if h.Pending() != 0 {
+		t.Fatalf("Pending after Reset = %d", h.Pending())
+	}
+}
+
+func TestHasherSnapshot(t *testing.T) {
+	data := make([]byte, 4*rate+9)
+	for i := range data {
+		data[i] = byte(i * 29)
+	}
+	var h Hasher
+	for i := 0; i < len(data); {
+		n := min(1+i%37, len(data)-i)
+		h.Write(data[i : i+n])
+		i += n
+		if got, want := h.Snapshot(), Sum256(data[:i]); got != want {
+			t.Fatalf("snapshot after %d bytes = %x, want %x", i, got, want)
+		}
+	}
+}
+
+// TestHasherWriteAfterSum locks in that Sum256 is a snapshot: writing may
+// continue after it, including from the middle of a block.
+func TestHasherWriteAfterSum(t *testing.T) {
+	var h Hasher
+	h.Write([]byte("abc"))
+	if got, want := h.Sum256(), Sum256([]byte("abc")); got != want {
+		t.Fatalf("first snapshot = %x, want %x", got, want)
+	}
+	h.Write([]byte("def"))
+	if got, want := h.Sum256(), Sum256([]byte("abcdef")); got != want {
+		t.Fatalf("second snapshot = %x, want %x", got, want)
+	}
+
+	// The bytes that follow a snapshot continue the carry buffer, both
+	// before the first permutation and after it, and whether or not they
+	// complete the block.
+	data := make([]byte, 3*rate)
+	for i := range data {
+		data[i] = byte(i*41 + 9)
+	}
+	for _, mid := range []int{1, 50, rate - 1, rate + 1, 2*rate - 1} {
+		for _, next := range []int{1, rate - mid%rate, rate - mid%rate + 1, rate} {
+			var h Hasher
+			h.Write(data[:mid])
+			if got, want := h.Sum256(), Sum256(data[:mid]); got != want {
+				t.Fatalf("mid=%d: snapshot = %x, want %x", mid, got, want)
+			}
+			h.Write(data[mid : mid+next])
+			if got, want := h.Sum256(), Sum256(data[:mid+next]); got != want {
+				t.Errorf("mid=%d next=%d: continued = %x, want %x", mid, next, got, want)
+			}
+		}
+	}
+}
+
+// TestHasherSum256LeavesState holds Sum256 to "does not modify the hasher
+// state" byte for byte, carry buffer included, on hashers that have
+// permuted, whether by writing or by resuming an x/crypto state.
+func TestHasherSum256LeavesState(t *testing.T) {
+	data := make([]byte, 4*rate)
+	for i := range data {
+		data[i] = byte(i*53 + 5)
+	}
+	written := func(k int) *Hasher {
+		h := new(Hasher)
+		h.Write(data[:k])
+		return h
+	}
+	resumed := func(k int) *Hasher {
+		ref := sha3.NewLegacyKeccak256()
+		ref.Write(data[:k])
+		state, err := ref.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := new(Hasher)
+		if err := h.UnmarshalBinaryCompat(state); err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	for _, k := range []int{rate, rate + 1, rate + 50, 2*rate - 1} {
+		for name, h := range map[string]*Hasher{"written": written(k), "resumed": resumed(k)} {
+			before := h.sponge
+			h.Sum256()
+			if h.sponge != before {
+				t.Fatalf("%s k=%d: Sum256 modified the hasher", name, k)
+			}
+
+			// Write, Sum256, Write, Sum256 matches a fresh hasher fed the
+			// concatenated input at each step.
+			off := k
+			for _, end := range []int{k + 3, len(data)} {
+				h.Write(data[off:end])
+				off = end
+				var fresh Hasher
+				fresh.Write(data[:end])
+				if got, want := h.Sum256(), fresh.Sum256(); got != want {
+					t.Errorf("%s: Sum256 after %d bytes = %x, want %x", name, end, got, want)
+				}
+				if h.Pending() != fresh.Pending() {
+					t.Errorf("%s: Pending after %d bytes = %d, want %d", name, end, h.Pending(), fresh.Pending())
+				}
+			}
+		}
+	}
+}
+
+func TestSum256Domain(t *testing.T) {
+	for _, n := range []int{0, 1, rate - 1, rate, 2*rate + 3} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i*3 + 1)
+		}
+		if got, want := Sum256Domain(data, 0x01), Sum256(data); got != want {
+			t.Errorf("len=%d: Sum256Domain(0x01) = %x, want Sum256 %x", n, got, want)
+		}
+		if got, want := Sum256Domain(data, 0x06), sha3.Sum256(data); got != want {
+			t.Errorf("len=%d: Sum256Domain(0x06) = %x, want SHA3-256 %x", n, got, want)
+		}
+		if Sum256Domain(data, 0x02) == Sum256(data) {
+			t.Errorf("len=%d: domain 0x02 collides with Keccak-256", n)
+		}
+	}
+	for _, domain := range []byte{0x00, 0x80, 0xFF} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Sum256Domain with domain %#x did not panic", domain)
+				}
+			}()
+			Sum256Domain(nil, domain)
+		}()
+	}
+}
+
+func TestSum256Buffers(t *testing.T) {
+	data := make([]byte, 3*rate+40)
+	for i := range data {
+		data[i] = byte(i*19 + 3)
+	}
+	for _, splits := range [][]int{
+		nil,
+		{0},
+		{135, 140}, // one byte short of a block, then straddling it
+		{1, 2, 3, rate + 1},
+		{rate, rate, 2 * rate},
+		{10, 10, 10, 300}, // empty buffers in between
+	} {
+		var bufs net.Buffers
+		prev := 0
+		for _, cut := range splits {
+			bufs = append(bufs, data[prev:cut])
+			prev = cut
+		}
+		bufs = append(bufs, data[prev:])
+		if got, want := Sum256Buffers(bufs), Sum256(data); got != want {
+			t.Errorf("splits %v: got %x, want %x", splits, got, want)
+		}
+	}
+	bufs := [][]byte{data[:135], data[135:140], data[140:]}
+	if n := testing.AllocsPerRun(100, func() { Sum256Buffers(bufs) }); n != 0 {
+		t.Errorf("Sum256Buffers: got %v allocs, want 0", n)
+	}
+}
+
+func TestSetPermutation(t *testing.T) {
+	defer SetPermutation(nil)
+	data := make([]byte, 3*rate+11)
+	for i := range data {
+		data[i] = byte(i * 17)
+	}
+	want := Sum256(data)
+
+	calls := 0
+	SetPermutation(func(a *[200]byte) {
+		calls++
+		keccakF1600Generic(a)
+	})
+	var h Hasher
+	h.Write(data[:rate+1])
+	h.Write(data[rate+1:])
+	if got := Sum256(data); got != want {
+		t.Errorf("Sum256 through the generic permutation = %x, want %x", got, want)
+	}
+	if got := h.Sum256(); got != want {
+		t.Errorf("Hasher through the generic permutation = %x, want %x", got, want)
+	}
+	if calls != 8 {
+		t.Errorf("permutation called %d times, want 8", calls)
+	}
+
+	SetPermutation(nil)
+	calls = 0
+	if got := Sum256(data); got != want || calls != 0 {
+		t.Fatalf("after SetPermutation(nil): Sum256 = %x with %d override calls", got, calls)
+	}
+}
+
+// TestPermutationInlines keeps the SetPermutation hook off the hot path:
+// keccakF1600 and xorAndPermute must inline to a direct call of the
+// platform entry point.
+func TestPermutationInlines(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the compiler")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	out, err := exec.Command(goTool, "build", "-gcflags=-m", "-o", os.DevNull, ".").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	for _, fn := range []string{"keccakF1600", "xorAndPermute"} {
+		if !regexp.MustCompile(`(?m): can inline ` + fn + `$`).Match(out) {
+			t.Errorf("%s is not inlinable", fn)
+		}
+	}
+}
+
+func TestHasherWriteBlockBoundary(t *testing.T) {
+	defer SetPermutation(nil)
+	calls := 0
+	SetPermutation(func(a *[200]byte) {
+		calls++
+		keccakF1600Generic(a)
+	})
+	data := make([]byte, 3*rate)
+	for i := range data {
+		data[i] = byte(i*29 + 3)
+	}
+
+	tests := []struct {
+		name   string
+		writes []int // lengths of consecutive writes
+		perms  []int // permutations completed after each write
+	}{
+		// A one-byte write completes a buffer holding rate-1 bytes, then
+		// writing continues into a fresh block.
+		{"rate-1+1", []int{rate - 1, 1, 5, rate - 5}, []int{0, 1, 1, 2}},
+		// The carried bytes complete the block and leave p empty, so the
+		// whole-block loop and the tail copy both see nothing.
+		{"fill-exact", []int{10, rate - 10, 1}, []int{0, 1, 1}},
+		// The carried bytes complete the block and a whole block follows.
+		{"fill-then-block", []int{10, 2*rate - 10, 7}, []int{0, 2, 2}},
+		// Whole blocks written straight from p with nothing carried.
+		{"exact-blocks", []int{rate, 2 * rate}, []int{1, 3}},
+		{"empty-writes", []int{0, rate - 1, 0, 1, 0}, []int{0, 0, 0, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h Hasher
+			calls = 0
+			off := 0
+			for i, n := range tt.writes {
+				h.Write(data[off : off+n])
+				off += n
+				if calls != tt.perms[i] || h.Pending() != off%rate {
+					t.Fatalf("after write %d (%d bytes): %d permutations and %d pending, want %d and %d",
+						i, n, calls, h.Pending(), tt.perms[i], off%rate)
+				}
+			}
+			got := h.Sum256()
+			// Exactly one more permutation for the padded final block.
+			if want := tt.perms[len(tt.perms)-1] + 1; calls != want {
+				t.Fatalf("%d permutations after Sum256, want %d", calls, want)
+			}
+			if want := Sum256(data[:off]); got != want {
+				t.Fatalf("Sum256 = %x, want %x", got, want)
+			}
+		})
+	}
+}
+
+func TestHasherWriteZeros(t *testing.T) {
+	prefix := make([]byte, 2*rate)
+	for i := range prefix {
+		prefix[i] = byte(i*9 + 1)
+	}
+	for _, p := range []int{0, 1, rate - 1, rate, rate + 5} {
+		for _, n := range []int{0, 1, rate - p%rate, rate - 1, rate, rate + 1, 3*rate + 2, 1 << 16} {
+			var h Hasher
+			// Leave stale bytes past absorbed in the carry buffer.
+			h.Write(prefix[:p])
+			h.Sum256()
+			h.WriteZeros(n)
+			h.Write([]byte("tail"))
+
+			want := Sum256(append(append(append([]byte{}, prefix[:p]...), make([]byte, n)...), "tail"...))
+			if got := h.Sum256(); got != want {
+				t.Errorf("prefix=%d n=%d: got %x, want %x", p, n, got, want)
+			}
+		}
+	}
+
+	var h Hasher
+	if n := testing.AllocsPerRun(100, func() { h.WriteZeros(3*rate + 7) }); n != 0 {
+		t.Errorf("WriteZeros: got %v allocs, want 0", n)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("WriteZeros(-1) did not panic")
+			}
+		}()
+		h.WriteZeros(-1)
+	}()
+}
+
+func FuzzHasherWriteLanes(f *testing.F) {
+	f.Add([]byte(nil), uint8(0))
+	f.Add(make([]byte, 2*rate), uint8(0))
+	f.Add(make([]byte, 3*rate+40), uint8(5))
+	f.Fuzz(func(t *testing.T, data []byte, prefix uint8) {
+		lanes := make([]uint64, len(data)/8)
+		for i := range lanes {
+			lanes[i] = binary.LittleEndian.Uint64(data[i*8:])
+		}
+		head := data[:min(int(prefix)%rate, len(data))]
+
+		// A byte prefix leaves the lanes unaligned to the block.
+		var h Hasher
+		h.Write(head)
+		h.WriteLanes(lanes)
+		want := Sum256(append(append([]byte(nil), head...), data[:8*len(lanes)]...))
+		if got := h.Sum256(); got != want {
+			t.Fatalf("prefix=%d lanes=%d: WriteLanes = %x, want %x", len(head), len(lanes), got, want)
+		}
+	})
+}
+
+func TestHasherWriteChunks(t *testing.T) {
+	data := make([]byte, 8<<20)
+	NewRand([]byte("chunks")).Read(data)
+	r := NewRand([]byte("sizes"))
+	var h Hasher
+	for off := 0; off < len(data); {
+		var n int
+		switch k := r.Uint64(); k % 4 {
+		case 0:
+			n = 0
+		case 1:
+			n = int((k >> 8) % uint64(2*rate))
+		case 2:
+			n = int((k >> 8) % (64 << 10))
+		default:
+			n = int((k >> 8) % (2 << 20)) // large slices
+		}
+		n = min(n, len(data)-off)
+		if m, err := h.Write(data[off : off+n]); m != n || err != nil {
+			t.Fatalf("Write(%d bytes) = %d, %v", n, m, err)
+		}
+		off += n
+		if got, want := h.Snapshot(), Sum256(data[:off]); got != want {
+			t.Fatalf("after %d bytes: running digest = %x, want %x", off, got, want)
+		}
+	}
+}
+
+func TestSizeConstants(t *testing.T) {
+	if BlockSize != rate || Size != 32 {
+		t.Fatalf("BlockSize, Size = %d, %d; want %d, 32", BlockSize, Size, rate)
+	}
+	var h Hasher
+	var s SafeHasher
+	if h.BlockSize() != BlockSize || h.Size() != Size || s.BlockSize() != BlockSize || s.Size() != Size {
+		t.Fatal("Hasher or SafeHasher sizes disagree with the package constants")
+	}
+}
+
+func TestNewLegacyKeccak256(t *testing.T) {
+	h, ref := NewLegacyKeccak256(), sha3.NewLegacyKeccak256()
+	if h.Size() != ref.Size() || h.BlockSize() != ref.BlockSize() {
+		t.Fatalf("Size, BlockSize = %d, %d; want %d, %d", h.Size(), h.BlockSize(), ref.Size(), ref.BlockSize())
+	}
+	if _, ok := h.(KeccakState); !ok {
+		t.Fatal("NewLegacyKeccak256 does not return a KeccakState")
+	}
+	data := make([]byte, 3*rate+9)
+	for i := range data {
+		data[i] = byte(i * 5)
+	}
+	check := func(step string) {
+		t.Helper()
+		if got, want := h.Sum([]byte("x")), ref.Sum([]byte("x")); !bytes.Equal(got, want) {
+			t.Fatalf("%s: Sum = %x, want %x", step, got, want)
+		}
+	}
+	check("empty")
+	for i, n := range []int{1, rate, 0, 2*rate + 8} {
+		h.Write(data[:n])
+		ref.Write(data[:n])
+		check(fmt.Sprintf("write %d", i))
+		if i%2 == 1 {
+			h.Reset()
+			ref.Reset()
+			check(fmt.Sprintf("reset %d", i))
+		}
+	}
+}
+
+func TestHasherWriteIntegers(t *testing.T) {
+	var h Hasher
+	var want []byte
+	for i := 0; i < 40; i++ {
+		x := uint64(i)*0x9e3779b97f4a7c15 + 1
+		h.WriteUint64(x)
+		want = binary.LittleEndian.AppendUint64(want, x)
+		h.WriteUint32(uint32(x >> 7))
+		want = binary.LittleEndian.AppendUint32(want, uint32(x>>7))
+		h.WriteByte(byte(x))
+		want = append(want, byte(x))
+	}
+	if got := h.Sum256(); got != Sum256(want) {
+		t.Fatalf("integer writes = %x, want %x", got, Sum256(want))
+	}
+}
+
 func FuzzSum256(f *testing.F) {
 	f.Add([]byte(nil))
 	f.Add([]byte("hello"))
@@ -246,6 +924,295 @@ func FuzzSum256(f *testing.F) {
 	})
 }
 
+func TestSum256Append(t *testing.T) {
+	data := []byte("append me")
+	want := Sum256(data)
+	if got := Sum256Append(nil, data); !bytes.Equal(got, want[:]) {
+		t.Fatalf("Sum256Append(nil) = %x, want %x", got, want)
+	}
+
+	dst := append(make([]byte, 0, 64), "prefix"...)
+	got := Sum256Append(dst, data)
+	if string(got[:6]) != "prefix" || !bytes.Equal(got[6:], want[:]) || &got[0] != &dst[:1][0] {
+		t.Fatalf("Sum256Append(prefix) = %x", got)
+	}
+
+	if testing.AllocsPerRun(10, func() { Sum256(data) }) != 0 {
+		t.Skip("Sum256 allocates on this platform")
+	}
+	if n := testing.AllocsPerRun(100, func() { Sum256Append(dst[:0], data) }); n != 0 {
+		t.Fatalf("Sum256Append with spare capacity allocated %v times", n)
+	}
+}
+
+// TestZeroAllocSum256 holds every one-shot entry point documented as
+// allocation-free to it, across sizes that stay within one block, end on a
+// block boundary, and span several blocks.
+func TestZeroAllocSum256(t *testing.T) {
+	if testing.AllocsPerRun(10, func() { Sum256(nil) }) != 0 {
+		t.Skip("Sum256 allocates on this platform")
+	}
+	var a, b [32]byte
+	var scratch [200]byte
+	var sink [32]byte
+	for _, n := range []int{0, 1, 32, rate - 1, rate, rate + 1, 4096} {
+		data := make([]byte, n)
+		str := string(data)
+		for _, tc := range []struct {
+			name string
+			f    func() [32]byte
+		}{
+			{"Sum256", func() [32]byte { return Sum256(data) }},
+			{"Sum256String", func() [32]byte { return Sum256String(str) }},
+			{"Sum256Hash", func() [32]byte { return Sum256Hash(data) }},
+			{"Sum256WithState", func() [32]byte { return Sum256WithState(&scratch, data) }},
+			{"Sum256Pooled", func() [32]byte { return Sum256Pooled(data) }},
+			{"Sum256Double", func() [32]byte { return Sum256Double(data) }},
+			{"Sum256Framed", func() [32]byte { return Sum256Framed(data, data) }},
+			{"Sum256Domain", func() [32]byte { return Sum256Domain(data, 0x06) }},
+			{"SHA3Sum256", func() [32]byte { return SHA3Sum256(data) }},
+			{"HMAC256", func() [32]byte { return HMAC256(data, data) }},
+			{"MappingSlot", func() [32]byte { return MappingSlot(data, a) }},
+			{"Commit", func() [32]byte { return Commit(data, data) }},
+			{"HashPair", func() [32]byte { return HashPair(a, b) }},
+			{"Sum256Of32", func() [32]byte { return Sum256Of32(a) }},
+			{"StorageSlot", func() [32]byte { return StorageSlot(a, b) }},
+			{"Sum256Word", func() [32]byte { return Sum256Word([4]uint64{1, 2, 3, 4}) }},
+			{"HashEthereumMessage", func() [32]byte { return HashEthereumMessage(data) }},
+			{"Sum256Hex", func() [32]byte { h := Sum256Hex(data); return [32]byte(h[:32]) }},
+		} {
+			if allocs := testing.AllocsPerRun(20, func() { sink = tc.f() }); allocs != 0 {
+				t.Errorf("%s(len=%d): got %v allocs, want 0", tc.name, n, allocs)
+			}
+		}
+	}
+	_ = sink
+}
+
+func TestSum256WithState(t *testing.T) {
+	var scratch [200]byte
+	for _, n := range []int{0, 1, 135, 136, 137, 272, 1000} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i * 5)
+		}
+		for i := range scratch {
+			scratch[i] = 0xAA // stale contents must not leak into the digest
+		}
+		if got, want := Sum256WithState(&scratch, data), Sum256(data); got != want {
+			t.Errorf("len=%d: Sum256WithState = %x, want %x", n, got, want)
+		}
+	}
+
+	data := make([]byte, 300)
+	if n := testing.AllocsPerRun(100, func() { Sum256WithState(&scratch, data) }); n != 0 {
+		t.Fatalf("Sum256WithState allocated %v times", n)
+	}
+}
+
+func TestSum256Truncated(t *testing.T) {
+	data := []byte("truncate me")
+	full := Sum256(data)
+	var h Hasher
+	h.Write(data)
+	stream := make([]byte, 3*rate)
+	h.Read(stream)
+
+	for _, n := range []int{0, 20, 32, 48, rate, 2*rate + 1} {
+		got := Sum256Truncated(data, n)
+		if len(got) != n || !bytes.Equal(got, stream[:n]) {
+			t.Errorf("n=%d: got %x, want %x", n, got, stream[:n])
+		}
+	}
+	if got := Sum256Truncated(data, 20); !bytes.Equal(got, full[:20]) {
+		t.Errorf("n=20: got %x, want prefix of %x", got, full)
+	}
+}
+
+// FuzzHasherSum256Short covers Hasher.Sum256 before the first block, which
+// finalizes the buffered bytes on a fresh zero state instead of copying the
+// sponge's. Marking a copy of the hasher permuted forces the copying path
+// on the same, still zero, state, and both must match x/crypto.
+func FuzzHasherSum256Short(f *testing.F) {
+	f.Add([]byte(nil), uint8(0))
+	f.Add([]byte("hello"), uint8(2))
+	f.Add(make([]byte, rate-1), uint8(100))
+	f.Fuzz(func(t *testing.T, data []byte, split uint8) {
+		data = data[:min(len(data), rate-1)]
+		i := int(split) % (len(data) + 1)
+		var h Hasher
+		h.Write(data[:i])
+		h.Write(data[i:])
+		copied := h
+		copied.permuted = true
+
+		ref := sha3.NewLegacyKeccak256()
+		ref.Write(data)
+		want := [32]byte(ref.Sum(nil))
+		if got := h.Sum256(); got != want {
+			t.Fatalf("len=%d split=%d: zero-state Sum256 = %x, want %x", len(data), i, got, want)
+		}
+		if got := copied.Sum256(); got != want {
+			t.Fatalf("len=%d split=%d: state-copy Sum256 = %x, want %x", len(data), i, got, want)
+		}
+	})
+}
+
+// FuzzHasherSum256Permuted covers Hasher.Sum256 past the first block, where
+// the tail is padded in a local block, against x/crypto: the input is
+// written in three parts with a snapshot after each, both into a fresh
+// Hasher and into one resumed from x/crypto's state after the first part.
+func FuzzHasherSum256Permuted(f *testing.F) {
+	f.Add(make([]byte, rate+1), uint16(rate), uint16(1))
+	f.Add(make([]byte, 2*rate), uint16(rate+7), uint16(0))
+	f.Add(make([]byte, 3*rate+50), uint16(10), uint16(2*rate))
+	f.Fuzz(func(t *testing.T, data []byte, split1, split2 uint16) {
+		if len(data) <= rate {
+			t.Skip("FuzzSum256 covers inputs that never permute")
+		}
+		i := int(split1) % (len(data) + 1)
+		j := i + int(split2)%(len(data)-i+1)
+
+		ref := sha3.NewLegacyKeccak256()
+		var h, resumed Hasher
+		for n, part := range [][]byte{data[:i], data[i:j], data[j:]} {
+			ref.Write(part)
+			h.Write(part)
+			if n == 0 {
+				state, err := ref.(encoding.BinaryMarshaler).MarshalBinary()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := resumed.UnmarshalBinaryCompat(state); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				resumed.Write(part)
+			}
+			want := ref.Sum(nil)
+			if got := h.Sum256(); !bytes.Equal(got[:], want) {
+				t.Fatalf("splits %d,%d part %d: Hasher = %x, want %x", i, j, n, got, want)
+			}
+			if got := resumed.Sum256(); !bytes.Equal(got[:], want) {
+				t.Fatalf("splits %d,%d part %d: resumed Hasher = %x, want %x", i, j, n, got, want)
+			}
+		}
+	})
+}
+
+func FuzzSum256String(f *testing.F) {
+	f.Add("")
+	f.Add("hello")
+	f.Add(string(make([]byte, rate+1)))
+	f.Fuzz(func(t *testing.T, s string) {
+		if got, want := Sum256String(s), Sum256([]byte(s)); got != want {
+			t.Fatalf("Sum256String mismatch for len=%d\ngot:  %x\nwant: %x", len(s), got, want)
+		}
+	})
+}
+
+func FuzzSum256Of32(f *testing.F) {
+	f.Add(make([]byte, 32))
+	f.Add([]byte("0123456789abcdef0123456789abcdef"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var in [32]byte
+		copy(in[:], data)
+		if got, want := Sum256Of32(in), Sum256(in[:]); got != want {
+			t.Fatalf("Sum256Of32(%x) = %x, want %x", in, got, want)
+		}
+	})
+}
+
+func TestSum256Of32HashOfHash(t *testing.T) {
+	d := Sum256([]byte("hello"))
+	want := Sum256(d[:])
+	if got := Sum256Of32(d); got != want {
+		t.Fatalf("Sum256Of32(Sum256(hello)) = %x, want %x", got, want)
+	}
+	if n := testing.AllocsPerRun(100, func() { d = Sum256Of32(d) }); n != 0 {
+		t.Fatalf("Sum256Of32 allocated %v times", n)
+	}
+}
+
+func TestSum256Double(t *testing.T) {
+	for _, n := range []int{0, 1, 32, 135, 136, 137, 1000} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i * 3)
+		}
+		first := Sum256(data)
+		if got, want := Sum256Double(data), Sum256(first[:]); got != want {
+			t.Errorf("len=%d: Sum256Double = %x, want %x", n, got, want)
+		}
+	}
+	data := make([]byte, 300)
+	if n := testing.AllocsPerRun(100, func() { Sum256Double(data) }); n != 0 {
+		t.Fatalf("Sum256Double allocated %v times", n)
+	}
+}
+
+func TestSum256Framed(t *testing.T) {
+	ab := Sum256Framed([]byte("ab"))
+	for _, items := range [][][]byte{
+		{[]byte("a"), []byte("b")},
+		{[]byte("ab"), nil},
+		{nil, []byte("ab")},
+		{[]byte("a"), []byte("b"), nil},
+	} {
+		if Sum256Framed(items...) == ab {
+			t.Errorf("Sum256Framed(%q) equals Sum256Framed(\"ab\")", items)
+		}
+	}
+	if Sum256Framed() == Sum256Framed(nil) {
+		t.Error("Sum256Framed() equals Sum256Framed(nil)")
+	}
+
+	// The framing is LeftEncode(len) || item over plain Keccak-256.
+	long := make([]byte, 300)
+	var want []byte
+	for _, item := range [][]byte{[]byte("ab"), long, nil} {
+		want = append(want, LeftEncode(uint64(len(item)))...)
+		want = append(want, item...)
+	}
+	if got := Sum256Framed([]byte("ab"), long, nil); got != Sum256(want) {
+		t.Fatalf("Sum256Framed = %x, want %x", got, Sum256(want))
+	}
+
+	items := [][]byte{[]byte("ab"), long}
+	if n := testing.AllocsPerRun(100, func() { Sum256Framed(items...) }); n != 0 {
+		t.Fatalf("Sum256Framed allocated %v times", n)
+	}
+}
+
+func TestSum256Records(t *testing.T) {
+	data := make([]byte, 10*rate+7)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	for _, size := range []int{1, 32, rate, rate + 1, 3 * rate, len(data), len(data) + 5} {
+		got := Sum256Records(data, size)
+		if want := (len(data) + size - 1) / size; len(got) != want {
+			t.Fatalf("size=%d: %d digests, want %d", size, len(got), want)
+		}
+		for i, d := range got {
+			rec := data[i*size : min((i+1)*size, len(data))]
+			if d != Sum256(rec) {
+				t.Fatalf("size=%d: record %d = %x, want %x", size, i, d, Sum256(rec))
+			}
+		}
+	}
+	if got := Sum256Records(nil, 32); len(got) != 0 {
+		t.Fatalf("Sum256Records(nil) = %x, want no digests", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Sum256Records with recordSize 0 did not panic")
+		}
+	}()
+	Sum256Records(data, 0)
+}
+
 // Comparison benchmarks: faster_keccak vs golang.org/x/crypto/sha3.
 var benchSizes = []int{32, 128, 256, 1024, 4096, 500 * 1024}
 
@@ -272,6 +1239,22 @@ func BenchmarkFasterKeccak(b *testing.B) {
 	}
 }
 
+func BenchmarkSum256Small(b *testing.B) {
+	for _, size := range []int{32, 64, 128, rate - 1} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		b.Run(benchName(size), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for b.Loop() {
+				Sum256(data)
+			}
+		})
+	}
+}
+
 func BenchmarkXCrypto(b *testing.B) {
 	for _, size := range benchSizes {
 		data := make([]byte, size)
@@ -310,6 +1293,145 @@ func BenchmarkFasterKeccakHasher(b *testing.B) {
 	}
 }
 
+// BenchmarkHasherChunked writes 64 KiB in chunks of each size. Sizes that
+// do not divide the rate leave bytes in the carry buffer, so most of their
+// writes take the absorbed > 0 path of Write.
+// BenchmarkHasherSum256Short compares the two ways Hasher.Sum256 can
+// finalize input shorter than a block: on a fresh zero state, which it does
+// before the first permutation, or by copying the sponge's state, which it
+// does afterwards and which marking the hasher permuted forces here.
+func BenchmarkHasherSum256Short(b *testing.B) {
+	for _, size := range []int{32, 128} {
+		for _, copied := range []bool{false, true} {
+			name := benchName(size) + "/zero-state"
+			if copied {
+				name = benchName(size) + "/state-copy"
+			}
+			b.Run(name, func(b *testing.B) {
+				var h Hasher
+				h.Write(make([]byte, size))
+				h.permuted = copied
+				b.ReportAllocs()
+				for b.Loop() {
+					h.Sum256()
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkHasherSum256Permuted measures Hasher.Sum256 past the first
+// block, which pads the tail in a local block and absorbs it with one
+// xorAndPermute. "unfused" is the sequence that replaced: XOR the tail into
+// a copy of the state, flip the two padding bits there, then permute.
+func BenchmarkHasherSum256Permuted(b *testing.B) {
+	for _, size := range []int{rate + 32, 2*rate + 100} {
+		var h Hasher
+		h.Write(make([]byte, size))
+		b.Run(benchName(size)+"/fused", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				h.Sum256()
+			}
+		})
+		b.Run(benchName(size)+"/unfused", func(b *testing.B) {
+			b.ReportAllocs()
+			s := &h.sponge
+			for b.Loop() {
+				state := s.state
+				xorIn(&state, s.buf[:s.absorbed])
+				state[s.absorbed] ^= 0x01
+				state[rate-1] ^= 0x80
+				keccakF1600(&state)
+			}
+		})
+	}
+}
+
+func BenchmarkHasherChunked(b *testing.B) {
+	data := make([]byte, 64*1024)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	for _, chunk := range []int{1, 7, 64, 136, 137, 4096} {
+		b.Run(fmt.Sprint(chunk), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			var h Hasher
+			for b.Loop() {
+				h.Reset()
+				for p := data; len(p) > 0; {
+					n := min(chunk, len(p))
+					h.Write(p[:n])
+					p = p[n:]
+				}
+				h.Sum256()
+			}
+		})
+	}
+}
+
+func BenchmarkSumInPlace(b *testing.B) {
+	data := make([]byte, 64)
+	for _, bc := range []struct {
+		name string
+		sum  func(*Hasher) [32]byte
+	}{
+		{"Sum256", (*Hasher).Sum256},
+		{"SumInPlace", (*Hasher).SumInPlace},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			var h Hasher
+			for b.Loop() {
+				h.Reset()
+				h.Write(data)
+				bc.sum(&h)
+			}
+		})
+	}
+}
+
+// BenchmarkHasherReset measures Reset alone after a short message that fit
+// in the buffer and after one that permuted the state.
+func BenchmarkHasherReset(b *testing.B) {
+	for _, n := range []int{32, 2 * rate} {
+		data := make([]byte, n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			var h Hasher
+			for b.Loop() {
+				h.Write(data)
+				h.Reset()
+			}
+		})
+	}
+}
+
+func BenchmarkSum256Reset(b *testing.B) {
+	data := make([]byte, 64)
+	b.Run("Sum256+Reset", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		var h Hasher
+		for b.Loop() {
+			h.Write(data)
+			h.Sum256()
+			h.Reset()
+		}
+	})
+	b.Run("Sum256Reset", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		var h Hasher
+		for b.Loop() {
+			h.Write(data)
+			h.Sum256Reset()
+		}
+	})
+}
+
 // BenchmarkKeccakStreaming_Sha3 benchmarks the standard sha3 streaming hasher (Reset+Write+Read).
 func BenchmarkKeccakStreaming_Sha3(b *testing.B) {
 	data := make([]byte, 32)
@@ -326,3 +1448,122 @@ func BenchmarkKeccakStreaming_Sha3(b *testing.B) {
 		h.Read(buf[:])
 	}
 }
+
+func BenchmarkHasherWriteUint64(b *testing.B) {
+	var h Hasher
+	b.SetBytes(8)
+	b.ReportAllocs()
+	for i := uint64(0); b.Loop(); i++ {
+		h.WriteUint64(i)
+	}
+}
+
+func BenchmarkSum256WithState(b *testing.B) {
+	scratch := new([200]byte)
+	benchScratch = scratch // keep the scratch state on the heap
+	for _, size := range []int{32, 256} {
+		data := make([]byte, size)
+		b.Run(benchName(size), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for b.Loop() {
+				Sum256WithState(scratch, data)
+			}
+		})
+	}
+}
+
+var benchScratch *[200]byte
+
+func BenchmarkSum256String(b *testing.B) {
+	s := string(make([]byte, 1024))
+	b.Run("String", func(b *testing.B) {
+		b.SetBytes(int64(len(s)))
+		b.ReportAllocs()
+		for b.Loop() {
+			Sum256String(s)
+		}
+	})
+	b.Run("Convert", func(b *testing.B) {
+		b.SetBytes(int64(len(s)))
+		b.ReportAllocs()
+		for b.Loop() {
+			Sum256([]byte(s))
+		}
+	})
+}
+
+func BenchmarkSum256Of32(b *testing.B) {
+	var in [32]byte
+	b.Run("Of32", func(b *testing.B) {
+		b.SetBytes(32)
+		b.ReportAllocs()
+		for b.Loop() {
+			in = Sum256Of32(in)
+		}
+	})
+	b.Run("Sum256", func(b *testing.B) {
+		b.SetBytes(32)
+		b.ReportAllocs()
+		for b.Loop() {
+			in = Sum256(in[:])
+		}
+	})
+}
+
+func BenchmarkSum256Double(b *testing.B) {
+	data := make([]byte, 64)
+	b.Run("Double", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for b.Loop() {
+			Sum256Double(data)
+		}
+	})
+	b.Run("Twice", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for b.Loop() {
+			d := Sum256(data)
+			Sum256(d[:])
+		}
+	})
+}
+
+func BenchmarkHasherSnapshot(b *testing.B) {
+	record := make([]byte, 48)
+	var h Hasher
+	b.SetBytes(int64(len(record)))
+	b.ReportAllocs()
+	for b.Loop() {
+		h.Write(record)
+		h.Snapshot()
+	}
+}
+
+// BenchmarkBatchSum256 hashes 1024 32-byte records, the shape of a Merkle
+// leaf layer, in one Sum256Records call and in a loop of Sum256.
+func BenchmarkBatchSum256(b *testing.B) {
+	const recordSize = 32
+	data := make([]byte, 1024*recordSize)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	b.Run("Batch", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for b.Loop() {
+			Sum256Records(data, recordSize)
+		}
+	})
+	b.Run("Loop", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for b.Loop() {
+			out := make([][32]byte, len(data)/recordSize)
+			for i := range out {
+				out[i] = Sum256(data[i*recordSize : (i+1)*recordSize])
+			}
+		}
+	})
+}
@@ -0,0 +1,269 @@
+// Code generated by gen_keccakf_avx.go. DO NOT EDIT.
+
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+DATA rhoLeft<>+0(SB)/8, $1
+DATA rhoLeft<>+8(SB)/8, $62
+DATA rhoLeft<>+16(SB)/8, $28
+DATA rhoLeft<>+24(SB)/8, $27
+DATA rhoLeft<>+32(SB)/8, $36
+DATA rhoLeft<>+40(SB)/8, $3
+DATA rhoLeft<>+48(SB)/8, $41
+DATA rhoLeft<>+56(SB)/8, $18
+DATA rhoLeft<>+64(SB)/8, $45
+DATA rhoLeft<>+72(SB)/8, $6
+DATA rhoLeft<>+80(SB)/8, $56
+DATA rhoLeft<>+88(SB)/8, $39
+DATA rhoLeft<>+96(SB)/8, $10
+DATA rhoLeft<>+104(SB)/8, $61
+DATA rhoLeft<>+112(SB)/8, $55
+DATA rhoLeft<>+120(SB)/8, $8
+DATA rhoLeft<>+128(SB)/8, $2
+DATA rhoLeft<>+136(SB)/8, $15
+DATA rhoLeft<>+144(SB)/8, $25
+DATA rhoLeft<>+152(SB)/8, $20
+DATA rhoLeft<>+160(SB)/8, $44
+DATA rhoLeft<>+168(SB)/8, $43
+DATA rhoLeft<>+176(SB)/8, $21
+DATA rhoLeft<>+184(SB)/8, $14
+GLOBL rhoLeft<>(SB), RODATA|NOPTR, $192
+
+DATA rhoRight<>+0(SB)/8, $63
+DATA rhoRight<>+8(SB)/8, $2
+DATA rhoRight<>+16(SB)/8, $36
+DATA rhoRight<>+24(SB)/8, $37
+DATA rhoRight<>+32(SB)/8, $28
+DATA rhoRight<>+40(SB)/8, $61
+DATA rhoRight<>+48(SB)/8, $23
+DATA rhoRight<>+56(SB)/8, $46
+DATA rhoRight<>+64(SB)/8, $19
+DATA rhoRight<>+72(SB)/8, $58
+DATA rhoRight<>+80(SB)/8, $8
+DATA rhoRight<>+88(SB)/8, $25
+DATA rhoRight<>+96(SB)/8, $54
+DATA rhoRight<>+104(SB)/8, $3
+DATA rhoRight<>+112(SB)/8, $9
+DATA rhoRight<>+120(SB)/8, $56
+DATA rhoRight<>+128(SB)/8, $62
+DATA rhoRight<>+136(SB)/8, $49
+DATA rhoRight<>+144(SB)/8, $39
+DATA rhoRight<>+152(SB)/8, $44
+DATA rhoRight<>+160(SB)/8, $20
+DATA rhoRight<>+168(SB)/8, $21
+DATA rhoRight<>+176(SB)/8, $43
+DATA rhoRight<>+184(SB)/8, $50
+GLOBL rhoRight<>(SB), RODATA|NOPTR, $192
+
+// func keccakF1600AVX2(a *[200]byte, buf *byte, rounds int)
+TEXT ·keccakF1600AVX2(SB), NOSPLIT, $0-24
+	MOVQ a+0(FP), DI
+	MOVQ buf+8(FP), BX
+	TESTQ BX, BX
+	JZ load
+
+	// XOR 136 bytes of buf into state.
+	VMOVDQU 0(BX), Y0
+	VPXOR 0(DI), Y0, Y0
+	VMOVDQU Y0, 0(DI)
+	VMOVDQU 32(BX), Y0
+	VPXOR 32(DI), Y0, Y0
+	VMOVDQU Y0, 32(DI)
+	VMOVDQU 64(BX), Y0
+	VPXOR 64(DI), Y0, Y0
+	VMOVDQU Y0, 64(DI)
+	VMOVDQU 96(BX), Y0
+	VPXOR 96(DI), Y0, Y0
+	VMOVDQU Y0, 96(DI)
+	MOVQ 128(BX), AX
+	XORQ AX, 128(DI)
+
+load:
+	VPBROADCASTQ 0(DI), Y0
+	VMOVDQU 8(DI), Y1
+	VMOVQ 40(DI), X2
+	VPINSRQ $1, 80(DI), X2, X2
+	VMOVQ 120(DI), X15
+	VPINSRQ $1, 160(DI), X15, X15
+	VINSERTI128 $1, X15, Y2, Y2
+	VMOVQ 128(DI), X3
+	VPINSRQ $1, 56(DI), X3, X3
+	VMOVQ 184(DI), X15
+	VPINSRQ $1, 112(DI), X15, X15
+	VINSERTI128 $1, X15, Y3, Y3
+	VMOVQ 88(DI), X4
+	VPINSRQ $1, 176(DI), X4, X4
+	VMOVQ 64(DI), X15
+	VPINSRQ $1, 152(DI), X15, X15
+	VINSERTI128 $1, X15, Y4, Y4
+	VMOVQ 168(DI), X5
+	VPINSRQ $1, 136(DI), X5, X5
+	VMOVQ 104(DI), X15
+	VPINSRQ $1, 72(DI), X15, X15
+	VINSERTI128 $1, X15, Y5, Y5
+	VMOVQ 48(DI), X6
+	VPINSRQ $1, 96(DI), X6, X6
+	VMOVQ 144(DI), X15
+	VPINSRQ $1, 192(DI), X15, X15
+	VINSERTI128 $1, X15, Y6, Y6
+
+	// CX walks the round constants from round 24-rounds to DX.
+	LEAQ ·roundConstants+192(SB), DX
+	MOVQ rounds+16(FP), AX
+	SHLQ $3, AX
+	MOVQ DX, CX
+	SUBQ AX, CX
+
+loop:
+	// Theta.
+	VPXOR Y3, Y1, Y7
+	VPXOR Y4, Y7, Y7
+	VPXOR Y5, Y7, Y7
+	VPXOR Y6, Y7, Y7
+	VPSHUFD $0x4e, Y2, Y8
+	VPXOR Y2, Y8, Y8
+	VPERMQ $0x4e, Y8, Y11
+	VPXOR Y11, Y8, Y8
+	VPXOR Y0, Y8, Y8
+	VPSRLQ $63, Y7, Y11
+	VPADDQ Y7, Y7, Y9
+	VPOR Y11, Y9, Y9
+	VPSRLQ $63, Y8, Y11
+	VPADDQ Y8, Y8, Y10
+	VPOR Y11, Y10, Y10
+	VPERMQ $0x93, Y7, Y11
+	VPBLENDD $0x03, Y8, Y11, Y11
+	VPERMQ $0x39, Y9, Y12
+	VPBLENDD $0xc0, Y10, Y12, Y12
+	VPERMQ $0xff, Y7, Y13
+	VPERMQ $0x00, Y9, Y14
+	VPXOR Y12, Y11, Y11
+	VPXOR Y14, Y13, Y13
+	VPXOR Y13, Y0, Y0
+	VPXOR Y13, Y2, Y2
+	VPXOR Y11, Y1, Y1
+	VPXOR Y11, Y3, Y3
+	VPXOR Y11, Y4, Y4
+	VPXOR Y11, Y5, Y5
+	VPXOR Y11, Y6, Y6
+	// Rho.
+	VPSLLVQ rhoLeft<>+0(SB), Y1, Y7
+	VPSRLVQ rhoRight<>+0(SB), Y1, Y1
+	VPOR Y7, Y1, Y1
+	VPSLLVQ rhoLeft<>+32(SB), Y2, Y7
+	VPSRLVQ rhoRight<>+32(SB), Y2, Y2
+	VPOR Y7, Y2, Y2
+	VPSLLVQ rhoLeft<>+64(SB), Y3, Y7
+	VPSRLVQ rhoRight<>+64(SB), Y3, Y3
+	VPOR Y7, Y3, Y3
+	VPSLLVQ rhoLeft<>+96(SB), Y4, Y7
+	VPSRLVQ rhoRight<>+96(SB), Y4, Y4
+	VPOR Y7, Y4, Y4
+	VPSLLVQ rhoLeft<>+128(SB), Y5, Y7
+	VPSRLVQ rhoRight<>+128(SB), Y5, Y5
+	VPOR Y7, Y5, Y5
+	VPSLLVQ rhoLeft<>+160(SB), Y6, Y7
+	VPSRLVQ rhoRight<>+160(SB), Y6, Y6
+	VPOR Y7, Y6, Y6
+	// Pi.
+	VMOVDQA Y6, Y7
+	VPERMQ $0x1b, Y5, Y6
+	VPERMQ $0x8d, Y4, Y5
+	VMOVDQA Y3, Y4
+	VPERMQ $0x8d, Y2, Y3
+	VPERMQ $0x72, Y1, Y2
+	VMOVDQA Y7, Y1
+	// Chi.
+	VPBLENDD $0x0c, Y4, Y6, Y7
+	VPBLENDD $0x30, Y3, Y7, Y7
+	VPBLENDD $0xc0, Y5, Y7, Y7
+	VPBLENDD $0x0c, Y6, Y3, Y12
+	VPBLENDD $0x30, Y5, Y12, Y12
+	VPBLENDD $0xc0, Y4, Y12, Y12
+	VPANDN Y12, Y7, Y7
+	VPXOR Y2, Y7, Y7
+	VPBLENDD $0x0c, Y2, Y4, Y8
+	VPBLENDD $0x30, Y5, Y8, Y8
+	VPBLENDD $0xc0, Y6, Y8, Y8
+	VPBLENDD $0x0c, Y4, Y5, Y12
+	VPBLENDD $0x30, Y6, Y12, Y12
+	VPBLENDD $0xc0, Y2, Y12, Y12
+	VPANDN Y12, Y8, Y8
+	VPXOR Y3, Y8, Y8
+	VPBLENDD $0x0c, Y6, Y5, Y9
+	VPBLENDD $0x30, Y2, Y9, Y9
+	VPBLENDD $0xc0, Y3, Y9, Y9
+	VPBLENDD $0x0c, Y5, Y2, Y12
+	VPBLENDD $0x30, Y3, Y12, Y12
+	VPBLENDD $0xc0, Y6, Y12, Y12
+	VPANDN Y12, Y9, Y9
+	VPXOR Y4, Y9, Y9
+	VPBLENDD $0x0c, Y3, Y2, Y10
+	VPBLENDD $0x30, Y6, Y10, Y10
+	VPBLENDD $0xc0, Y4, Y10, Y10
+	VPBLENDD $0x0c, Y2, Y6, Y12
+	VPBLENDD $0x30, Y4, Y12, Y12
+	VPBLENDD $0xc0, Y3, Y12, Y12
+	VPANDN Y12, Y10, Y10
+	VPXOR Y5, Y10, Y10
+	VPBLENDD $0x0c, Y5, Y3, Y11
+	VPBLENDD $0x30, Y4, Y11, Y11
+	VPBLENDD $0xc0, Y2, Y11, Y11
+	VPBLENDD $0x0c, Y3, Y4, Y12
+	VPBLENDD $0x30, Y2, Y12, Y12
+	VPBLENDD $0xc0, Y5, Y12, Y12
+	VPANDN Y12, Y11, Y11
+	VPXOR Y6, Y11, Y11
+	VMOVDQA Y7, Y2
+	VPERMQ $0x72, Y8, Y3
+	VPERMQ $0x8d, Y9, Y4
+	VPERMQ $0x1b, Y10, Y5
+	VMOVDQA Y11, Y6
+	VPERMQ $0xf9, Y1, Y7
+	VPBLENDD $0xc0, Y0, Y7, Y7
+	VPERMQ $0x2e, Y1, Y12
+	VPBLENDD $0x30, Y0, Y12, Y12
+	VPANDN Y12, Y7, Y7
+	VPXOR Y1, Y7, Y7
+	VPERMQ $0x00, Y1, Y8
+	VPERMQ $0x55, Y1, Y12
+	VPANDN Y12, Y8, Y8
+	VPXOR Y0, Y8, Y8
+	VMOVDQA Y7, Y1
+	// Iota.
+	VPBROADCASTQ (CX), Y12
+	VPXOR Y12, Y8, Y0
+	ADDQ $8, CX
+	CMPQ CX, DX
+	JNE loop
+
+	VMOVQ X0, 0(DI)
+	VMOVDQU Y1, 8(DI)
+	VMOVQ X2, 40(DI)
+	VPEXTRQ $1, X2, 80(DI)
+	VEXTRACTI128 $1, Y2, X15
+	VMOVQ X15, 120(DI)
+	VPEXTRQ $1, X15, 160(DI)
+	VMOVQ X3, 128(DI)
+	VPEXTRQ $1, X3, 56(DI)
+	VEXTRACTI128 $1, Y3, X15
+	VMOVQ X15, 184(DI)
+	VPEXTRQ $1, X15, 112(DI)
+	VMOVQ X4, 88(DI)
+	VPEXTRQ $1, X4, 176(DI)
+	VEXTRACTI128 $1, Y4, X15
+	VMOVQ X15, 64(DI)
+	VPEXTRQ $1, X15, 152(DI)
+	VMOVQ X5, 168(DI)
+	VPEXTRQ $1, X5, 136(DI)
+	VEXTRACTI128 $1, Y5, X15
+	VMOVQ X15, 104(DI)
+	VPEXTRQ $1, X15, 72(DI)
+	VMOVQ X6, 48(DI)
+	VPEXTRQ $1, X6, 96(DI)
+	VEXTRACTI128 $1, Y6, X15
+	VMOVQ X15, 144(DI)
+	VPEXTRQ $1, X15, 192(DI)
+	VZEROUPPER
+	RET
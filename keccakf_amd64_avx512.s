@@ -0,0 +1,213 @@
+// Code generated by gen_keccakf_avx.go. DO NOT EDIT.
+
+//go:build amd64 && !purego
+
+#include "textflag.h"
+
+DATA rhoLeft<>+0(SB)/8, $1
+DATA rhoLeft<>+8(SB)/8, $62
+DATA rhoLeft<>+16(SB)/8, $28
+DATA rhoLeft<>+24(SB)/8, $27
+DATA rhoLeft<>+32(SB)/8, $36
+DATA rhoLeft<>+40(SB)/8, $3
+DATA rhoLeft<>+48(SB)/8, $41
+DATA rhoLeft<>+56(SB)/8, $18
+DATA rhoLeft<>+64(SB)/8, $45
+DATA rhoLeft<>+72(SB)/8, $6
+DATA rhoLeft<>+80(SB)/8, $56
+DATA rhoLeft<>+88(SB)/8, $39
+DATA rhoLeft<>+96(SB)/8, $10
+DATA rhoLeft<>+104(SB)/8, $61
+DATA rhoLeft<>+112(SB)/8, $55
+DATA rhoLeft<>+120(SB)/8, $8
+DATA rhoLeft<>+128(SB)/8, $2
+DATA rhoLeft<>+136(SB)/8, $15
+DATA rhoLeft<>+144(SB)/8, $25
+DATA rhoLeft<>+152(SB)/8, $20
+DATA rhoLeft<>+160(SB)/8, $44
+DATA rhoLeft<>+168(SB)/8, $43
+DATA rhoLeft<>+176(SB)/8, $21
+DATA rhoLeft<>+184(SB)/8, $14
+GLOBL rhoLeft<>(SB), RODATA|NOPTR, $192
+
+// func keccakF1600AVX512(a *[200]byte, buf *byte, rounds int)
+TEXT ·keccakF1600AVX512(SB), NOSPLIT, $0-24
+	MOVQ a+0(FP), DI
+	MOVQ buf+8(FP), BX
+	TESTQ BX, BX
+	JZ load
+
+	// XOR 136 bytes of buf into state.
+	VMOVDQU 0(BX), Y0
+	VPXOR 0(DI), Y0, Y0
+	VMOVDQU Y0, 0(DI)
+	VMOVDQU 32(BX), Y0
+	VPXOR 32(DI), Y0, Y0
+	VMOVDQU Y0, 32(DI)
+	VMOVDQU 64(BX), Y0
+	VPXOR 64(DI), Y0, Y0
+	VMOVDQU Y0, 64(DI)
+	VMOVDQU 96(BX), Y0
+	VPXOR 96(DI), Y0, Y0
+	VMOVDQU Y0, 96(DI)
+	MOVQ 128(BX), AX
+	XORQ AX, 128(DI)
+
+load:
+	VPBROADCASTQ 0(DI), Y0
+	VMOVDQU 8(DI), Y1
+	VMOVQ 40(DI), X2
+	VPINSRQ $1, 80(DI), X2, X2
+	VMOVQ 120(DI), X15
+	VPINSRQ $1, 160(DI), X15, X15
+	VINSERTI128 $1, X15, Y2, Y2
+	VMOVQ 128(DI), X3
+	VPINSRQ $1, 56(DI), X3, X3
+	VMOVQ 184(DI), X15
+	VPINSRQ $1, 112(DI), X15, X15
+	VINSERTI128 $1, X15, Y3, Y3
+	VMOVQ 88(DI), X4
+	VPINSRQ $1, 176(DI), X4, X4
+	VMOVQ 64(DI), X15
+	VPINSRQ $1, 152(DI), X15, X15
+	VINSERTI128 $1, X15, Y4, Y4
+	VMOVQ 168(DI), X5
+	VPINSRQ $1, 136(DI), X5, X5
+	VMOVQ 104(DI), X15
+	VPINSRQ $1, 72(DI), X15, X15
+	VINSERTI128 $1, X15, Y5, Y5
+	VMOVQ 48(DI), X6
+	VPINSRQ $1, 96(DI), X6, X6
+	VMOVQ 144(DI), X15
+	VPINSRQ $1, 192(DI), X15, X15
+	VINSERTI128 $1, X15, Y6, Y6
+	MOVL $0x1, AX
+	KMOVW AX, K1
+	MOVL $0x2, AX
+	KMOVW AX, K2
+	MOVL $0x4, AX
+	KMOVW AX, K3
+	MOVL $0x8, AX
+	KMOVW AX, K4
+
+	// CX walks the round constants from round 24-rounds to DX.
+	LEAQ ·roundConstants+192(SB), DX
+	MOVQ rounds+16(FP), AX
+	SHLQ $3, AX
+	MOVQ DX, CX
+	SUBQ AX, CX
+
+loop:
+	// Theta.
+	VMOVDQA Y1, Y7
+	VPTERNLOGQ $0x96, Y4, Y3, Y7
+	VPTERNLOGQ $0x96, Y6, Y5, Y7
+	VPSHUFD $0x4e, Y2, Y8
+	VPXOR Y2, Y8, Y8
+	VPERMQ $0x4e, Y8, Y11
+	VPTERNLOGQ $0x96, Y0, Y11, Y8
+	VPROLQ $1, Y7, Y9
+	VPROLQ $1, Y8, Y10
+	VPERMQ $0x93, Y7, Y11
+	VPBLENDD $0x03, Y8, Y11, Y11
+	VPERMQ $0x39, Y9, Y12
+	VPBLENDD $0xc0, Y10, Y12, Y12
+	VPERMQ $0xff, Y7, Y13
+	VPERMQ $0x00, Y9, Y14
+	VPTERNLOGQ $0x96, Y14, Y13, Y0
+	VPTERNLOGQ $0x96, Y14, Y13, Y2
+	VPTERNLOGQ $0x96, Y12, Y11, Y1
+	VPTERNLOGQ $0x96, Y12, Y11, Y3
+	VPTERNLOGQ $0x96, Y12, Y11, Y4
+	VPTERNLOGQ $0x96, Y12, Y11, Y5
+	VPTERNLOGQ $0x96, Y12, Y11, Y6
+	// Rho.
+	VPROLVQ rhoLeft<>+0(SB), Y1, Y1
+	VPROLVQ rhoLeft<>+32(SB), Y2, Y2
+	VPROLVQ rhoLeft<>+64(SB), Y3, Y3
+	VPROLVQ rhoLeft<>+96(SB), Y4, Y4
+	VPROLVQ rhoLeft<>+128(SB), Y5, Y5
+	VPROLVQ rhoLeft<>+160(SB), Y6, Y6
+	// Pi.
+	VMOVDQA Y6, Y7
+	VPERMQ $0x1b, Y5, Y6
+	VPERMQ $0x8d, Y4, Y5
+	VMOVDQA Y3, Y4
+	VPERMQ $0x8d, Y2, Y3
+	VPERMQ $0x72, Y1, Y2
+	VMOVDQA Y7, Y1
+	// Chi.
+	VMOVDQA Y2, Y7
+	VPTERNLOGQ $0xd2, Y3, Y6, K1, Y7
+	VPTERNLOGQ $0xd2, Y6, Y4, K2, Y7
+	VPTERNLOGQ $0xd2, Y5, Y3, K3, Y7
+	VPTERNLOGQ $0xd2, Y4, Y5, K4, Y7
+	VMOVDQA Y3, Y8
+	VPTERNLOGQ $0xd2, Y5, Y4, K1, Y8
+	VPTERNLOGQ $0xd2, Y4, Y2, K2, Y8
+	VPTERNLOGQ $0xd2, Y6, Y5, K3, Y8
+	VPTERNLOGQ $0xd2, Y2, Y6, K4, Y8
+	VMOVDQA Y4, Y9
+	VPTERNLOGQ $0xd2, Y2, Y5, K1, Y9
+	VPTERNLOGQ $0xd2, Y5, Y6, K2, Y9
+	VPTERNLOGQ $0xd2, Y3, Y2, K3, Y9
+	VPTERNLOGQ $0xd2, Y6, Y3, K4, Y9
+	VMOVDQA Y5, Y10
+	VPTERNLOGQ $0xd2, Y6, Y2, K1, Y10
+	VPTERNLOGQ $0xd2, Y2, Y3, K2, Y10
+	VPTERNLOGQ $0xd2, Y4, Y6, K3, Y10
+	VPTERNLOGQ $0xd2, Y3, Y4, K4, Y10
+	VMOVDQA Y6, Y11
+	VPTERNLOGQ $0xd2, Y4, Y3, K1, Y11
+	VPTERNLOGQ $0xd2, Y3, Y5, K2, Y11
+	VPTERNLOGQ $0xd2, Y2, Y4, K3, Y11
+	VPTERNLOGQ $0xd2, Y5, Y2, K4, Y11
+	VMOVDQA Y7, Y2
+	VPERMQ $0x72, Y8, Y3
+	VPERMQ $0x8d, Y9, Y4
+	VPERMQ $0x1b, Y10, Y5
+	VMOVDQA Y11, Y6
+	VPERMQ $0xf9, Y1, Y7
+	VPBLENDD $0xc0, Y0, Y7, Y7
+	VPERMQ $0x2e, Y1, Y12
+	VPBLENDD $0x30, Y0, Y12, Y12
+	VPTERNLOGQ $0xc6, Y12, Y1, Y7
+	VPERMQ $0x00, Y1, Y8
+	VPERMQ $0x55, Y1, Y12
+	VPTERNLOGQ $0xc6, Y12, Y0, Y8
+	VMOVDQA Y7, Y1
+	// Iota.
+	VPXORQ.BCST (CX), Y8, Y0
+	ADDQ $8, CX
+	CMPQ CX, DX
+	JNE loop
+
+	VMOVQ X0, 0(DI)
+	VMOVDQU Y1, 8(DI)
+	VMOVQ X2, 40(DI)
+	VPEXTRQ $1, X2, 80(DI)
+	VEXTRACTI128 $1, Y2, X15
+	VMOVQ X15, 120(DI)
+	VPEXTRQ $1, X15, 160(DI)
+	VMOVQ X3, 128(DI)
+	VPEXTRQ $1, X3, 56(DI)
+	VEXTRACTI128 $1, Y3, X15
+	VMOVQ X15, 184(DI)
+	VPEXTRQ $1, X15, 112(DI)
+	VMOVQ X4, 88(DI)
+	VPEXTRQ $1, X4, 176(DI)
+	VEXTRACTI128 $1, Y4, X15
+	VMOVQ X15, 64(DI)
+	VPEXTRQ $1, X15, 152(DI)
+	VMOVQ X5, 168(DI)
+	VPEXTRQ $1, X5, 136(DI)
+	VEXTRACTI128 $1, Y5, X15
+	VMOVQ X15, 104(DI)
+	VPEXTRQ $1, X15, 72(DI)
+	VMOVQ X6, 48(DI)
+	VPEXTRQ $1, X6, 96(DI)
+	VEXTRACTI128 $1, Y6, X15
+	VMOVQ X15, 144(DI)
+	VPEXTRQ $1, X15, 192(DI)
+	VZEROUPPER
+	RET
@@ -4,8 +4,8 @@
 
 #include "textflag.h"
 
-// func keccakF1600BMI2(a *[200]byte, buf *byte)
-TEXT ·keccakF1600BMI2(SB), NOSPLIT, $200-16
+// func keccakF1600BMI2(a *[200]byte, buf *byte, rounds int)
+TEXT ·keccakF1600BMI2(SB), NOSPLIT, $200-24
 	MOVQ a+0(FP), DI
 	MOVQ buf+8(FP), BX
 	TESTQ BX, BX
@@ -48,6 +48,8 @@ TEXT ·keccakF1600BMI2(SB), NOSPLIT, $200-16
 	XORQ AX, 128(DI)
 
 rounds:
+	CMPQ rounds+16(FP), $12
+	JEQ round12
 
 	// Round 0
 	MOVQ $0x0000000000000001, R13
@@ -2330,6 +2332,7 @@ rounds:
 	MOVQ AX, 192(DI)
 
 	// Round 12
+round12:
 	MOVQ $0x000000008000808b, R13
 	MOVQ 0(DI), AX
 	XORQ 40(DI), AX
@@ -6,14 +6,20 @@
 
 #include "textflag.h"
 
-// func keccakF1600Sha3(a *[200]byte, buf *byte)
+// func keccakF1600Sha3(a *[200]byte, buf *byte, rounds int)
 // When buf != nil, XORs rate bytes into state before permuting.
 // When buf == nil, just permutes.
-TEXT ·keccakF1600Sha3(SB), $200-16
+// Runs the last `rounds` rounds of Keccak-f[1600] (24 for the full permutation).
+TEXT ·keccakF1600Sha3(SB), $200-24
 	MOVD	a+0(FP), R0
 	MOVD	buf+8(FP), R3
 	MOVD	$round_consts<>(SB), R1
-	MOVD	$24, R2 // counter for loop
+	MOVD	rounds+16(FP), R2 // counter for loop
+
+	// Skip the round constants of the first 24-rounds rounds.
+	MOVD	$24, R4
+	SUB	R2, R4, R4
+	ADD	R4<<3, R1, R1
 
 	CBZ	R3, load_state
 
@@ -0,0 +1,379 @@
+package keccak
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"unsafe"
+)
+
+// roundConstants are the iota constants for the 24 rounds of Keccak-f[1600].
+var roundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082,
+	0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001,
+	0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088,
+	0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b,
+	0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080,
+	0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080,
+	0x0000000080000001, 0x8000000080008008,
+}
+
+// keccakF1600Generic applies the full 24-round Keccak-f[1600] in pure Go.
+func keccakF1600Generic(a *[200]byte) {
+	keccakF1600GenericRounds(a, 24)
+}
+
+// keccakF1600GenericRounds applies Keccak-p[1600, rounds] in pure Go: the last
+// rounds rounds of Keccak-f[1600], starting at round constant 24-rounds.
+// Lanes are little-endian in a. rounds must be a multiple of 4 in [0, 24].
+func keccakF1600GenericRounds(a *[200]byte, rounds int) {
+	keccakGeneric(a, nil, rounds)
+}
+
+// keccakGeneric XORs block, if not nil, into the leading lanes of a while
+// loading the state, then applies Keccak-p[1600, rounds]. Fusing the XOR
+// saves a pass over the state.
+func keccakGeneric(a *[200]byte, block *[rate]byte, rounds int) {
+	var s [25]uint64
+	for i := range s {
+		s[i] = binary.LittleEndian.Uint64(a[i*8:])
+	}
+	if block != nil {
+		xorInFullBlock(&s, block)
+	}
+
+	// This is the in-place schedule of the Keccak reference code (also used
+	// by crypto/sha3): theta, rho, pi, chi and iota are fused into one pass
+	// per plane, and instead of moving lanes for pi, each round stores lane
+	// (x, y) at a rotated position within its column. The positions cycle
+	// back every 4 rounds, so the loop is unrolled by 4.
+	var bc0, bc1, bc2, bc3, bc4, d0, d1, d2, d3, d4 uint64
+	for i := 24 - rounds; i < 24; i += 4 {
+		bc0 = s[0] ^ s[5] ^ s[10] ^ s[15] ^ s[20]
+		bc1 = s[1] ^ s[6] ^ s[11] ^ s[16] ^ s[21]
+		bc2 = s[2] ^ s[7] ^ s[12] ^ s[17] ^ s[22]
+		bc3 = s[3] ^ s[8] ^ s[13] ^ s[18] ^ s[23]
+		bc4 = s[4] ^ s[9] ^ s[14] ^ s[19] ^ s[24]
+		d0 = bc4 ^ bits.RotateLeft64(bc1, 1)
+		d1 = bc0 ^ bits.RotateLeft64(bc2, 1)
+		d2 = bc1 ^ bits.RotateLeft64(bc3, 1)
+		d3 = bc2 ^ bits.RotateLeft64(bc4, 1)
+		d4 = bc3 ^ bits.RotateLeft64(bc0, 1)
+
+		bc0 = s[0] ^ d0
+		bc1 = bits.RotateLeft64(s[6]^d1, 44)
+		bc2 = bits.RotateLeft64(s[12]^d2, 43)
+		bc3 = bits.RotateLeft64(s[18]^d3, 21)
+		bc4 = bits.RotateLeft64(s[24]^d4, 14)
+		s[0] = bc0 ^ (bc2 &^ bc1) ^ roundConstants[i]
+		s[6] = bc1 ^ (bc3 &^ bc2)
+		s[12] = bc2 ^ (bc4 &^ bc3)
+		s[18] = bc3 ^ (bc0 &^ bc4)
+		s[24] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[3]^d3, 28)
+		bc1 = bits.RotateLeft64(s[9]^d4, 20)
+		bc2 = bits.RotateLeft64(s[10]^d0, 3)
+		bc3 = bits.RotateLeft64(s[16]^d1, 45)
+		bc4 = bits.RotateLeft64(s[22]^d2, 61)
+		s[10] = bc0 ^ (bc2 &^ bc1)
+		s[16] = bc1 ^ (bc3 &^ bc2)
+		s[22] = bc2 ^ (bc4 &^ bc3)
+		s[3] = bc3 ^ (bc0 &^ bc4)
+		s[9] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[1]^d1, 1)
+		bc1 = bits.RotateLeft64(s[7]^d2, 6)
+		bc2 = bits.RotateLeft64(s[13]^d3, 25)
+		bc3 = bits.RotateLeft64(s[19]^d4, 8)
+		bc4 = bits.RotateLeft64(s[20]^d0, 18)
+		s[20] = bc0 ^ (bc2 &^ bc1)
+		s[1] = bc1 ^ (bc3 &^ bc2)
+		s[7] = bc2 ^ (bc4 &^ bc3)
+		s[13] = bc3 ^ (bc0 &^ bc4)
+		s[19] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[4]^d4, 27)
+		bc1 = bits.RotateLeft64(s[5]^d0, 36)
+		bc2 = bits.RotateLeft64(s[11]^d1, 10)
+		bc3 = bits.RotateLeft64(s[17]^d2, 15)
+		bc4 = bits.RotateLeft64(s[23]^d3, 56)
+		s[5] = bc0 ^ (bc2 &^ bc1)
+		s[11] = bc1 ^ (bc3 &^ bc2)
+		s[17] = bc2 ^ (bc4 &^ bc3)
+		s[23] = bc3 ^ (bc0 &^ bc4)
+		s[4] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[2]^d2, 62)
+		bc1 = bits.RotateLeft64(s[8]^d3, 55)
+		bc2 = bits.RotateLeft64(s[14]^d4, 39)
+		bc3 = bits.RotateLeft64(s[15]^d0, 41)
+		bc4 = bits.RotateLeft64(s[21]^d1, 2)
+		s[15] = bc0 ^ (bc2 &^ bc1)
+		s[21] = bc1 ^ (bc3 &^ bc2)
+		s[2] = bc2 ^ (bc4 &^ bc3)
+		s[8] = bc3 ^ (bc0 &^ bc4)
+		s[14] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = s[0] ^ s[10] ^ s[20] ^ s[5] ^ s[15]
+		bc1 = s[6] ^ s[16] ^ s[1] ^ s[11] ^ s[21]
+		bc2 = s[12] ^ s[22] ^ s[7] ^ s[17] ^ s[2]
+		bc3 = s[18] ^ s[3] ^ s[13] ^ s[23] ^ s[8]
+		bc4 = s[24] ^ s[9] ^ s[19] ^ s[4] ^ s[14]
+		d0 = bc4 ^ bits.RotateLeft64(bc1, 1)
+		d1 = bc0 ^ bits.RotateLeft64(bc2, 1)
+		d2 = bc1 ^ bits.RotateLeft64(bc3, 1)
+		d3 = bc2 ^ bits.RotateLeft64(bc4, 1)
+		d4 = bc3 ^ bits.RotateLeft64(bc0, 1)
+
+		bc0 = s[0] ^ d0
+		bc1 = bits.RotateLeft64(s[16]^d1, 44)
+		bc2 = bits.RotateLeft64(s[7]^d2, 43)
+		bc3 = bits.RotateLeft64(s[23]^d3, 21)
+		bc4 = bits.RotateLeft64(s[14]^d4, 14)
+		s[0] = bc0 ^ (bc2 &^ bc1) ^ roundConstants[i+1]
+		s[16] = bc1 ^ (bc3 &^ bc2)
+		s[7] = bc2 ^ (bc4 &^ bc3)
+		s[23] = bc3 ^ (bc0 &^ bc4)
+		s[14] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[18]^d3, 28)
+		bc1 = bits.RotateLeft64(s[9]^d4, 20)
+		bc2 = bits.RotateLeft64(s[20]^d0, 3)
+		bc3 = bits.RotateLeft64(s[11]^d1, 45)
+		bc4 = bits.RotateLeft64(s[2]^d2, 61)
+		s[20] = bc0 ^ (bc2 &^ bc1)
+		s[11] = bc1 ^ (bc3 &^ bc2)
+		s[2] = bc2 ^ (bc4 &^ bc3)
+		s[18] = bc3 ^ (bc0 &^ bc4)
+		s[9] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[6]^d1, 1)
+		bc1 = bits.RotateLeft64(s[22]^d2, 6)
+		bc2 = bits.RotateLeft64(s[13]^d3, 25)
+		bc3 = bits.RotateLeft64(s[4]^d4, 8)
+		bc4 = bits.RotateLeft64(s[15]^d0, 18)
+		s[15] = bc0 ^ (bc2 &^ bc1)
+		s[6] = bc1 ^ (bc3 &^ bc2)
+		s[22] = bc2 ^ (bc4 &^ bc3)
+		s[13] = bc3 ^ (bc0 &^ bc4)
+		s[4] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[24]^d4, 27)
+		bc1 = bits.RotateLeft64(s[10]^d0, 36)
+		bc2 = bits.RotateLeft64(s[1]^d1, 10)
+		bc3 = bits.RotateLeft64(s[17]^d2, 15)
+		bc4 = bits.RotateLeft64(s[8]^d3, 56)
+		s[10] = bc0 ^ (bc2 &^ bc1)
+		s[1] = bc1 ^ (bc3 &^ bc2)
+		s[17] = bc2 ^ (bc4 &^ bc3)
+		s[8] = bc3 ^ (bc0 &^ bc4)
+		s[24] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[12]^d2, 62)
+		bc1 = bits.RotateLeft64(s[3]^d3, 55)
+		bc2 = bits.RotateLeft64(s[19]^d4, 39)
+		bc3 = bits.RotateLeft64(s[5]^d0, 41)
+		bc4 = bits.RotateLeft64(s[21]^d1, 2)
+		s[5] = bc0 ^ (bc2 &^ bc1)
+		s[21] = bc1 ^ (bc3 &^ bc2)
+		s[12] = bc2 ^ (bc4 &^ bc3)
+		s[3] = bc3 ^ (bc0 &^ bc4)
+		s[19] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = s[0] ^ s[20] ^ s[15] ^ s[10] ^ s[5]
+		bc1 = s[16] ^ s[11] ^ s[6] ^ s[1] ^ s[21]
+		bc2 = s[7] ^ s[2] ^ s[22] ^ s[17] ^ s[12]
+		bc3 = s[23] ^ s[18] ^ s[13] ^ s[8] ^ s[3]
+		bc4 = s[14] ^ s[9] ^ s[4] ^ s[24] ^ s[19]
+		d0 = bc4 ^ bits.RotateLeft64(bc1, 1)
+		d1 = bc0 ^ bits.RotateLeft64(bc2, 1)
+		d2 = bc1 ^ bits.RotateLeft64(bc3, 1)
+		d3 = bc2 ^ bits.RotateLeft64(bc4, 1)
+		d4 = bc3 ^ bits.RotateLeft64(bc0, 1)
+
+		bc0 = s[0] ^ d0
+		bc1 = bits.RotateLeft64(s[11]^d1, 44)
+		bc2 = bits.RotateLeft64(s[22]^d2, 43)
+		bc3 = bits.RotateLeft64(s[8]^d3, 21)
+		bc4 = bits.RotateLeft64(s[19]^d4, 14)
+		s[0] = bc0 ^ (bc2 &^ bc1) ^ roundConstants[i+2]
+		s[11] = bc1 ^ (bc3 &^ bc2)
+		s[22] = bc2 ^ (bc4 &^ bc3)
+		s[8] = bc3 ^ (bc0 &^ bc4)
+		s[19] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[23]^d3, 28)
+		bc1 = bits.RotateLeft64(s[9]^d4, 20)
+		bc2 = bits.RotateLeft64(s[15]^d0, 3)
+		bc3 = bits.RotateLeft64(s[1]^d1, 45)
+		bc4 = bits.RotateLeft64(s[12]^d2, 61)
+		s[15] = bc0 ^ (bc2 &^ bc1)
+		s[1] = bc1 ^ (bc3 &^ bc2)
+		s[12] = bc2 ^ (bc4 &^ bc3)
+		s[23] = bc3 ^ (bc0 &^ bc4)
+		s[9] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[16]^d1, 1)
+		bc1 = bits.RotateLeft64(s[2]^d2, 6)
+		bc2 = bits.RotateLeft64(s[13]^d3, 25)
+		bc3 = bits.RotateLeft64(s[24]^d4, 8)
+		bc4 = bits.RotateLeft64(s[5]^d0, 18)
+		s[5] = bc0 ^ (bc2 &^ bc1)
+		s[16] = bc1 ^ (bc3 &^ bc2)
+		s[2] = bc2 ^ (bc4 &^ bc3)
+		s[13] = bc3 ^ (bc0 &^ bc4)
+		s[24] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[14]^d4, 27)
+		bc1 = bits.RotateLeft64(s[20]^d0, 36)
+		bc2 = bits.RotateLeft64(s[6]^d1, 10)
+		bc3 = bits.RotateLeft64(s[17]^d2, 15)
+		bc4 = bits.RotateLeft64(s[3]^d3, 56)
+		s[20] = bc0 ^ (bc2 &^ bc1)
+		s[6] = bc1 ^ (bc3 &^ bc2)
+		s[17] = bc2 ^ (bc4 &^ bc3)
+		s[3] = bc3 ^ (bc0 &^ bc4)
+		s[14] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[7]^d2, 62)
+		bc1 = bits.RotateLeft64(s[18]^d3, 55)
+		bc2 = bits.RotateLeft64(s[4]^d4, 39)
+		bc3 = bits.RotateLeft64(s[10]^d0, 41)
+		bc4 = bits.RotateLeft64(s[21]^d1, 2)
+		s[10] = bc0 ^ (bc2 &^ bc1)
+		s[21] = bc1 ^ (bc3 &^ bc2)
+		s[7] = bc2 ^ (bc4 &^ bc3)
+		s[18] = bc3 ^ (bc0 &^ bc4)
+		s[4] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = s[0] ^ s[15] ^ s[5] ^ s[20] ^ s[10]
+		bc1 = s[11] ^ s[1] ^ s[16] ^ s[6] ^ s[21]
+		bc2 = s[22] ^ s[12] ^ s[2] ^ s[17] ^ s[7]
+		bc3 = s[8] ^ s[23] ^ s[13] ^ s[3] ^ s[18]
+		bc4 = s[19] ^ s[9] ^ s[24] ^ s[14] ^ s[4]
+		d0 = bc4 ^ bits.RotateLeft64(bc1, 1)
+		d1 = bc0 ^ bits.RotateLeft64(bc2, 1)
+		d2 = bc1 ^ bits.RotateLeft64(bc3, 1)
+		d3 = bc2 ^ bits.RotateLeft64(bc4, 1)
+		d4 = bc3 ^ bits.RotateLeft64(bc0, 1)
+
+		bc0 = s[0] ^ d0
+		bc1 = bits.RotateLeft64(s[1]^d1, 44)
+		bc2 = bits.RotateLeft64(s[2]^d2, 43)
+		bc3 = bits.RotateLeft64(s[3]^d3, 21)
+		bc4 = bits.RotateLeft64(s[4]^d4, 14)
+		s[0] = bc0 ^ (bc2 &^ bc1) ^ roundConstants[i+3]
+		s[1] = bc1 ^ (bc3 &^ bc2)
+		s[2] = bc2 ^ (bc4 &^ bc3)
+		s[3] = bc3 ^ (bc0 &^ bc4)
+		s[4] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[8]^d3, 28)
+		bc1 = bits.RotateLeft64(s[9]^d4, 20)
+		bc2 = bits.RotateLeft64(s[5]^d0, 3)
+		bc3 = bits.RotateLeft64(s[6]^d1, 45)
+		bc4 = bits.RotateLeft64(s[7]^d2, 61)
+		s[5] = bc0 ^ (bc2 &^ bc1)
+		s[6] = bc1 ^ (bc3 &^ bc2)
+		s[7] = bc2 ^ (bc4 &^ bc3)
+		s[8] = bc3 ^ (bc0 &^ bc4)
+		s[9] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[11]^d1, 1)
+		bc1 = bits.RotateLeft64(s[12]^d2, 6)
+		bc2 = bits.RotateLeft64(s[13]^d3, 25)
+		bc3 = bits.RotateLeft64(s[14]^d4, 8)
+		bc4 = bits.RotateLeft64(s[10]^d0, 18)
+		s[10] = bc0 ^ (bc2 &^ bc1)
+		s[11] = bc1 ^ (bc3 &^ bc2)
+		s[12] = bc2 ^ (bc4 &^ bc3)
+		s[13] = bc3 ^ (bc0 &^ bc4)
+		s[14] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[19]^d4, 27)
+		bc1 = bits.RotateLeft64(s[15]^d0, 36)
+		bc2 = bits.RotateLeft64(s[16]^d1, 10)
+		bc3 = bits.RotateLeft64(s[17]^d2, 15)
+		bc4 = bits.RotateLeft64(s[18]^d3, 56)
+		s[15] = bc0 ^ (bc2 &^ bc1)
+		s[16] = bc1 ^ (bc3 &^ bc2)
+		s[17] = bc2 ^ (bc4 &^ bc3)
+		s[18] = bc3 ^ (bc0 &^ bc4)
+		s[19] = bc4 ^ (bc1 &^ bc0)
+
+		bc0 = bits.RotateLeft64(s[22]^d2, 62)
+		bc1 = bits.RotateLeft64(s[23]^d3, 55)
+		bc2 = bits.RotateLeft64(s[24]^d4, 39)
+		bc3 = bits.RotateLeft64(s[20]^d0, 41)
+		bc4 = bits.RotateLeft64(s[21]^d1, 2)
+		s[20] = bc0 ^ (bc2 &^ bc1)
+		s[21] = bc1 ^ (bc3 &^ bc2)
+		s[22] = bc2 ^ (bc4 &^ bc3)
+		s[23] = bc3 ^ (bc0 &^ bc4)
+		s[24] = bc4 ^ (bc1 &^ bc0)
+	}
+
+	for i := range s {
+		binary.LittleEndian.PutUint64(a[i*8:], s[i])
+	}
+}
+
+// sum256Generic is sum256State on the generic permutation, for inputs below
+// the arm64 SetSHA3Threshold.
+func sum256Generic(state *[200]byte, data []byte) [32]byte {
+	for len(data) >= rate {
+		keccakGeneric(state, (*[rate]byte)(data), 24)
+		data = data[rate:]
+	}
+	var block [rate]byte
+	copy(block[:], data)
+	block[len(data)] = 0x01
+	block[rate-1] ^= 0x80
+	keccakGeneric(state, &block, 24)
+	return [32]byte(state[:32])
+}
+
+// xorAndPermuteGeneric XORs the rate bytes at buf into state and applies the
+// generic Keccak-f[1600].
+func xorAndPermuteGeneric(state *[200]byte, buf *byte) {
+	keccakGeneric(state, (*[rate]byte)(unsafe.Slice(buf, rate)), 24)
+}
+
+// xorInFullBlock XORs a full rate block into the first 17 lanes of s. It is
+// written out lane by lane, with no loop, so the compiler can check the
+// bounds once and schedule the loads freely.
+func xorInFullBlock(s *[25]uint64, data *[rate]byte) {
+	s[0] ^= binary.LittleEndian.Uint64(data[0:])
+	s[1] ^= binary.LittleEndian.Uint64(data[8:])
+	s[2] ^= binary.LittleEndian.Uint64(data[16:])
+	s[3] ^= binary.LittleEndian.Uint64(data[24:])
+	s[4] ^= binary.LittleEndian.Uint64(data[32:])
+	s[5] ^= binary.LittleEndian.Uint64(data[40:])
+	s[6] ^= binary.LittleEndian.Uint64(data[48:])
+	s[7] ^= binary.LittleEndian.Uint64(data[56:])
+	s[8] ^= binary.LittleEndian.Uint64(data[64:])
+	s[9] ^= binary.LittleEndian.Uint64(data[72:])
+	s[10] ^= binary.LittleEndian.Uint64(data[80:])
+	s[11] ^= binary.LittleEndian.Uint64(data[88:])
+	s[12] ^= binary.LittleEndian.Uint64(data[96:])
+	s[13] ^= binary.LittleEndian.Uint64(data[104:])
+	s[14] ^= binary.LittleEndian.Uint64(data[112:])
+	s[15] ^= binary.LittleEndian.Uint64(data[120:])
+	s[16] ^= binary.LittleEndian.Uint64(data[128:])
+}
+
+// xorIn XORs data into the first len(data) bytes of state using uint64 loads.
+func xorIn(state *[200]byte, data []byte) {
+	for i := 0; i+8 <= len(data); i += 8 {
+		v := binary.LittleEndian.Uint64(state[i:]) ^ binary.LittleEndian.Uint64(data[i:])
+		binary.LittleEndian.PutUint64(state[i:], v)
+	}
+	for i := len(data) &^ 7; i < len(data); i++ {
+		state[i] ^= data[i]
+	}
+}
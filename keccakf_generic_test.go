@@ -0,0 +1,302 @@
+package keccak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/bits"
+	"sync"
+	"testing"
+)
+
+// withGeneric runs f with the assembly permutation switched off, as on a CPU
+// that lacks it. It must not be used from parallel tests.
+func withGeneric(f func()) {
+	defer func(v bool) { useASM = v }(useASM)
+	useASM = false
+	f()
+}
+
+func TestKeccakF1600GenericKeccak256(t *testing.T) {
+	// A one-block Keccak-256 built directly on the generic permutation.
+	for _, msg := range []string{"", "hello", string(make([]byte, rate-1))} {
+		var state [200]byte
+		copy(state[:], msg)
+		state[len(msg)] ^= 0x01
+		state[rate-1] ^= 0x80
+		keccakF1600Generic(&state)
+		if got, want := [32]byte(state[:32]), Sum256([]byte(msg)); got != want {
+			t.Errorf("len=%d: generic = %x, want %x", len(msg), got, want)
+		}
+	}
+}
+
+func TestKeccakF1600GenericRounds12(t *testing.T) {
+	// The state after absorbing the empty message into TurboSHAKE128 with
+	// domain 0x1F and applying Keccak-p[1600, 12] starts with the RFC 9861
+	// TurboSHAKE128(M=empty, D=0x1F) output.
+	var state [200]byte
+	state[0] ^= 0x1F
+	state[turboShake128Rate-1] ^= 0x80
+	keccakF1600GenericRounds(&state, 12)
+	want := unhex("1E415F1C5983AFF2169217277D17BB538CD945A397DDEC541F1CE41AF2C1B74C")
+	if got := state[:32]; string(got) != string(want) {
+		t.Fatalf("Keccak-p[1600, 12] = %X, want %X", got, want)
+	}
+}
+
+func TestKeccakF1600GenericRoundsCompose(t *testing.T) {
+	// Zero rounds is the identity; 24 rounds equals the full permutation.
+	var a, b [200]byte
+	for i := range a {
+		a[i] = byte(i * 3)
+	}
+	b = a
+	keccakF1600GenericRounds(&b, 0)
+	if a != b {
+		t.Fatal("zero rounds changed the state")
+	}
+	keccakF1600Generic(&a)
+	keccakF1600GenericRounds(&b, 24)
+	if a != b {
+		t.Fatal("keccakF1600Generic differs from 24 rounds")
+	}
+}
+
+func TestMisalignedState(t *testing.T) {
+	// Lanes are read and written through encoding/binary, never by casting
+	// the state to [25]uint64, so a state at an odd address must work on
+	// strict-alignment targets too.
+	buf := make([]byte, 200+8)
+	data := make([]byte, 3*rate+5)
+	for i := range data {
+		data[i] = byte(i * 9)
+	}
+	want := Sum256(data)
+	for off := 1; off < 8; off++ {
+		a := (*[200]byte)(buf[off : off+200])
+		if got := Sum256WithState(a, data); got != want {
+			t.Fatalf("offset %d: Sum256WithState = %x, want %x", off, got, want)
+		}
+
+		var aligned [200]byte
+		for i := range a {
+			a[i] = byte(i + off)
+			aligned[i] = a[i]
+		}
+		xorIn(a, data[:rate])
+		keccakF1600Generic(a)
+		xorIn(&aligned, data[:rate])
+		keccakF1600Generic(&aligned)
+		if *a != aligned {
+			t.Fatalf("offset %d: misaligned permutation differs", off)
+		}
+	}
+}
+
+func BenchmarkKeccakF1600Generic(b *testing.B) {
+	var a [200]byte
+	b.SetBytes(rate)
+	b.ReportAllocs()
+	for b.Loop() {
+		keccakF1600Generic(&a)
+	}
+}
+
+func FuzzXorIn(f *testing.F) {
+	f.Add(make([]byte, rate), uint8(0))
+	f.Add(bytes.Repeat([]byte("0123456789abcdef"), 9)[:rate], uint8(3))
+	f.Add([]byte("abc"), uint8(7))
+	f.Fuzz(func(t *testing.T, data []byte, seed uint8) {
+		data = data[:min(len(data), rate)]
+		var state [200]byte
+		for i := range state {
+			state[i] = byte(i) ^ seed
+		}
+		want := state
+		for i, b := range data {
+			want[i] ^= b
+		}
+		got := state
+		xorIn(&got, data)
+		if got != want {
+			t.Fatalf("xorIn mismatch for %x", data)
+		}
+
+		// The generic absorb fuses the same XOR, via xorInFullBlock, into
+		// its lane loads.
+		if len(data) == rate {
+			var lanes [25]uint64
+			for i := range lanes {
+				lanes[i] = binary.LittleEndian.Uint64(state[i*8:])
+			}
+			xorInFullBlock(&lanes, (*[rate]byte)(data))
+			for i := range lanes {
+				if lanes[i] != binary.LittleEndian.Uint64(want[i*8:]) {
+					t.Fatalf("xorInFullBlock lane %d mismatch for %x", i, data)
+				}
+			}
+			keccakF1600Generic(&want)
+			xorAndPermuteGeneric(&state, &data[0])
+			if state != want {
+				t.Fatalf("xorAndPermuteGeneric mismatch for %x", data)
+			}
+		}
+	})
+}
+
+func TestSum256Generic(t *testing.T) {
+	for _, n := range []int{0, 1, 31, 32, rate - 1, rate, rate + 1, 3*rate + 5} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i*7 + 1)
+		}
+		var state [200]byte
+		if got, want := sum256Generic(&state, data), refSum256(data); got != want {
+			t.Errorf("len=%d: got %x, want %x", n, got, want)
+		}
+	}
+}
+
+func BenchmarkXorAndPermuteGeneric(b *testing.B) {
+	var state [200]byte
+	var block [rate]byte
+	b.SetBytes(rate)
+	b.ReportAllocs()
+	for b.Loop() {
+		xorAndPermuteGeneric(&state, &block[0])
+	}
+}
+
+// thetaInverse holds the rows of the inverse of theta's action on column
+// parities, a 320x320 matrix over GF(2) indexed by bit 64x+z of lane-column x.
+var thetaInverse = sync.OnceValue(func() *[320][5]uint64 {
+	// Theta maps parities C to C ^ L(C), where
+	// L(C)[x] = C[x-1] ^ rotl(C[x+1], 1). Build that matrix next to the
+	// identity and reduce it with Gauss-Jordan elimination.
+	var m, inv [320][5]uint64
+	for j := range 320 {
+		var c [5]uint64
+		c[j/64] = 1 << (j % 64)
+		var f [5]uint64
+		for x := range 5 {
+			f[x] = c[x] ^ c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+		}
+		for i := range 320 {
+			if f[i/64]>>(i%64)&1 != 0 {
+				m[i][j/64] |= 1 << (j % 64)
+			}
+		}
+		inv[j][j/64] = 1 << (j % 64)
+	}
+	for col := range 320 {
+		p := col
+		for m[p][col/64]>>(col%64)&1 == 0 {
+			p++
+		}
+		m[col], m[p] = m[p], m[col]
+		inv[col], inv[p] = inv[p], inv[col]
+		for i := range 320 {
+			if i != col && m[i][col/64]>>(col%64)&1 != 0 {
+				for w := range 5 {
+					m[i][w] ^= m[col][w]
+					inv[i][w] ^= inv[col][w]
+				}
+			}
+		}
+	}
+	return &inv
+})
+
+// keccakF1600Inverse undoes keccakF1600Generic. It exists only to check the
+// permutations: a lane stored in the wrong place by the forward direction
+// will not survive a round trip through it.
+func keccakF1600Inverse(a *[200]byte) {
+	var s, b [25]uint64
+	for i := range s {
+		s[i] = binary.LittleEndian.Uint64(a[i*8:])
+	}
+	tinv := thetaInverse()
+	for round := 23; round >= 0; round-- {
+		// iota
+		s[0] ^= roundConstants[round]
+
+		// chi, row by row: a_i = b_i ^ ~b_{i+1} & (b_{i+2} ^ ~b_{i+3} & b_{i+4})
+		for y := 0; y < 25; y += 5 {
+			var r [5]uint64
+			copy(r[:], s[y:y+5])
+			for x := range 5 {
+				s[y+x] = r[x] ^ (r[(x+2)%5]^(r[(x+4)%5]&^r[(x+3)%5]))&^r[(x+1)%5]
+			}
+		}
+
+		// pi and rho: lane (x, y) was rotated and moved to (y, 2x+3y).
+		b = s
+		for x := range 5 {
+			for y := range 5 {
+				s[x+5*y] = bits.RotateLeft64(b[y+5*((2*x+3*y)%5)], -rhoOffsets[x+5*y])
+			}
+		}
+
+		// theta: recover the original column parities, then undo the XOR.
+		var cp, c [5]uint64
+		for x := range 5 {
+			cp[x] = s[x] ^ s[x+5] ^ s[x+10] ^ s[x+15] ^ s[x+20]
+		}
+		for i := range 320 {
+			var par int
+			for w := range 5 {
+				par += bits.OnesCount64(tinv[i][w] & cp[w])
+			}
+			c[i/64] |= uint64(par&1) << (i % 64)
+		}
+		for x := range 5 {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				s[y+x] ^= d
+			}
+		}
+	}
+	for i := range s {
+		binary.LittleEndian.PutUint64(a[i*8:], s[i])
+	}
+}
+
+func TestKeccakF1600Inverse(t *testing.T) {
+	var a [200]byte
+	for i := range a {
+		a[i] = byte(i*11 + 5)
+	}
+	orig := a
+	keccakF1600Generic(&a)
+	keccakF1600Inverse(&a)
+	if a != orig {
+		t.Fatalf("inverse(forward(x)) != x:\ngot:  %x\nwant: %x", a, orig)
+	}
+	keccakF1600Inverse(&a)
+	keccakF1600Generic(&a)
+	if a != orig {
+		t.Fatalf("forward(inverse(x)) != x:\ngot:  %x\nwant: %x", a, orig)
+	}
+}
+
+// FuzzKeccakF1600RoundTrip runs the platform permutation, which is assembly
+// where available, and checks that the generic inverse restores the state.
+func FuzzKeccakF1600RoundTrip(f *testing.F) {
+	f.Add(make([]byte, 200))
+	f.Add([]byte("abc"))
+	f.Fuzz(func(t *testing.T, seed []byte) {
+		var a [200]byte
+		copy(a[:], seed)
+		orig := a
+		keccakF1600(&a)
+		want := orig
+		keccakF1600Generic(&want)
+		if a != want {
+			t.Fatalf("keccakF1600 differs from generic for state %x", seed)
+		}
+		keccakF1600Inverse(&a)
+		if a != orig {
+			t.Fatalf("round trip changed state %x:\ngot: %x", seed, a)
+		}
+	})
+}
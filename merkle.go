@@ -0,0 +1,151 @@
+package keccak
+
+// HashPair returns the Keccak-256 hash of a || b, the interior node of a
+// binary Merkle tree. The 64-byte input always fits one block.
+func HashPair(a, b [32]byte) [32]byte {
+	var state [200]byte
+	*(*[32]byte)(state[:]) = a
+	*(*[32]byte)(state[32:]) = b
+	state[64] = 0x01
+	state[rate-1] = 0x80
+	keccakF1600(&state)
+	return [32]byte(state[:32])
+}
+
+// MerkleTree is a binary Merkle tree over Keccak-256. Leaves are hashed
+// with Sum256 and interior nodes with HashPair, the plain convention of
+// Ethereum tooling. A level with an odd number of nodes pairs its last node
+// with itself, as Bitcoin does. That makes the leaf lists (a, b, c) and
+// (a, b, c, c) share a root, so commit to the leaf count alongside the root
+// where that matters.
+//
+// Leaves and interior nodes share one hash domain: the 64 bytes under a
+// node are also valid leaf data, so a proof cut short by one level verifies
+// that node as a leaf. Verifiers must check the proof length against the
+// depth they expect, or use NewPrefixedMerkleTree, where this cannot happen.
+//
+// The zero value is an empty tree ready to use.
+type MerkleTree struct {
+	leaves   [][32]byte
+	prefixed bool
+}
+
+// NewPrefixedMerkleTree returns an empty MerkleTree that hashes leaves and
+// interior nodes under different one-byte prefixes, as in RFC 6962: a leaf
+// is PrefixedMerkleLeaf(data), Keccak-256(0x00 || data), and a node is
+// Keccak-256(0x01 || left || right). No leaf data can hash to an interior
+// node, so a truncated proof cannot pass a subtree off as a leaf. Its roots
+// differ from those of the zero-value tree; check its proofs with
+// VerifyPrefixedMerkleProof.
+func NewPrefixedMerkleTree() *MerkleTree {
+	return &MerkleTree{prefixed: true}
+}
+
+// PrefixedMerkleLeaf returns Keccak-256(0x00 || data), the leaf hash of data
+// in a tree from NewPrefixedMerkleTree.
+func PrefixedMerkleLeaf(data []byte) [32]byte {
+	var s sponge
+	var prefix [1]byte // 0x00
+	s.Write(prefix[:])
+	s.Write(data)
+	return s.Sum256()
+}
+
+// prefixedMerkleNode returns Keccak-256(0x01 || a || b), an interior node
+// of a prefixed tree. The 65-byte input always fits one block.
+func prefixedMerkleNode(a, b [32]byte) [32]byte {
+	var state [200]byte
+	state[0] = 0x01
+	*(*[32]byte)(state[1:]) = a
+	*(*[32]byte)(state[33:]) = b
+	state[65] = 0x01
+	state[rate-1] = 0x80
+	keccakF1600(&state)
+	return [32]byte(state[:32])
+}
+
+// AddLeaf appends the leaf Sum256(data), or PrefixedMerkleLeaf(data) in a
+// prefixed tree.
+func (t *MerkleTree) AddLeaf(data []byte) {
+	if t.prefixed {
+		t.leaves = append(t.leaves, PrefixedMerkleLeaf(data))
+		return
+	}
+	t.leaves = append(t.leaves, Sum256(data))
+}
+
+// Len returns the number of leaves.
+func (t *MerkleTree) Len() int { return len(t.leaves) }
+
+// Root returns the root of the tree. A single leaf is its own root; an
+// empty tree has the zero root.
+func (t *MerkleTree) Root() [32]byte {
+	if len(t.leaves) == 0 {
+		return [32]byte{}
+	}
+	level := append([][32]byte(nil), t.leaves...)
+	for len(level) > 1 {
+		level = t.nextLevel(level)
+	}
+	return level[0]
+}
+
+// Proof returns the sibling hashes on the path from leaf index to the root,
+// bottom up, for VerifyMerkleProof or VerifyPrefixedMerkleProof.
+// Panics if index is out of range.
+func (t *MerkleTree) Proof(index int) [][32]byte {
+	if index < 0 || index >= len(t.leaves) {
+		panic("keccak: MerkleTree proof index out of range")
+	}
+	var proof [][32]byte
+	level := append([][32]byte(nil), t.leaves...)
+	for len(level) > 1 {
+		proof = append(proof, level[min(index^1, len(level)-1)])
+		level = t.nextLevel(level)
+		index >>= 1
+	}
+	return proof
+}
+
+// nextLevel hashes level pairwise in place and returns the parent level.
+func (t *MerkleTree) nextLevel(level [][32]byte) [][32]byte {
+	n := (len(level) + 1) / 2
+	for i := range n {
+		a, b := level[2*i], level[min(2*i+1, len(level)-1)]
+		if t.prefixed {
+			level[i] = prefixedMerkleNode(a, b)
+		} else {
+			level[i] = HashPair(a, b)
+		}
+	}
+	return level[:n]
+}
+
+// VerifyMerkleProof reports whether proof, as returned by MerkleTree.Proof,
+// shows that leaf, the Sum256 of the leaf data, sits at index in the tree
+// with the given root. It accepts a proof of any length; see MerkleTree.
+func VerifyMerkleProof(root, leaf [32]byte, index int, proof [][32]byte) bool {
+	return verifyMerkleProof(root, leaf, index, proof, HashPair)
+}
+
+// VerifyPrefixedMerkleProof is VerifyMerkleProof for a tree from
+// NewPrefixedMerkleTree: leaf is the PrefixedMerkleLeaf of the leaf data.
+func VerifyPrefixedMerkleProof(root, leaf [32]byte, index int, proof [][32]byte) bool {
+	return verifyMerkleProof(root, leaf, index, proof, prefixedMerkleNode)
+}
+
+func verifyMerkleProof(root, leaf [32]byte, index int, proof [][32]byte, node func(a, b [32]byte) [32]byte) bool {
+	if index < 0 || index>>len(proof) != 0 {
+		return false
+	}
+	h := leaf
+	for _, sibling := range proof {
+		if index&1 == 0 {
+			h = node(h, sibling)
+		} else {
+			h = node(sibling, h)
+		}
+		index >>= 1
+	}
+	return Equal(h, root)
+}
@@ -0,0 +1,173 @@
+package keccak
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func TestHashPair(t *testing.T) {
+	a, b := Sum256([]byte("a")), Sum256([]byte("b"))
+	if got, want := HashPair(a, b), Sum256(append(a[:], b[:]...)); got != want {
+		t.Fatalf("HashPair = %x, want %x", got, want)
+	}
+}
+
+func TestMerkleTree(t *testing.T) {
+	for _, v := range []struct {
+		name   string
+		tree   func() *MerkleTree
+		leaf   func(data []byte) [32]byte
+		pair   func(a, b [32]byte) [32]byte
+		verify func(root, leaf [32]byte, index int, proof [][32]byte) bool
+		roots  map[int]string // known roots, computed with x/crypto
+	}{
+		{
+			name:   "plain",
+			tree:   func() *MerkleTree { return new(MerkleTree) },
+			leaf:   func(data []byte) [32]byte { return Sum256(data) },
+			pair:   func(a, b [32]byte) [32]byte { return Sum256(append(a[:], b[:]...)) },
+			verify: VerifyMerkleProof,
+			roots: map[int]string{
+				1: "6fef85753a1881775100d9b0a36fd6c333db4e7f358b8413d3819b6246b66a30",
+				2: "c1b5d1ae49e22482d2d21501e7723143bd1f74b536aafc1439762ade2dd39168",
+				3: "63511c78b8c28f0605fb9e2302d11da41d739933af40f297ba38f4244c291ee4",
+				8: "a623bd9fcb26254ed641fd0b2b1ad40946d99fecd68431a776e0a90f95adbb2b",
+			},
+		},
+		{
+			name:   "prefixed",
+			tree:   NewPrefixedMerkleTree,
+			leaf:   func(data []byte) [32]byte { return Sum256(append([]byte{0x00}, data...)) },
+			pair:   func(a, b [32]byte) [32]byte { return Sum256(append(append([]byte{0x01}, a[:]...), b[:]...)) },
+			verify: VerifyPrefixedMerkleProof,
+			roots: map[int]string{
+				1: "e2c396c8b00b93ba00761d0b3efdacf6032d8074d85027495b2d33c4b6c4771b",
+				2: "bec2ae52c780e2f5be203303a9e8fcd5c08611a4c207e862766a5cb5a7ab8c5e",
+				3: "1f870dc900d7d58a426092d05f58685c44bc5d64242d86dfb60112e0c0be79e8",
+				8: "fa8fcbe196af69685ae29353073d39b9717d80836cf20e5cd8ba1fe1384bc54c",
+			},
+		},
+	} {
+		l := make([][32]byte, 8)
+		for i := range l {
+			l[i] = v.leaf([]byte(fmt.Sprint("leaf", i)))
+		}
+		pair := v.pair
+		n01, n23, n45, n67 := pair(l[0], l[1]), pair(l[2], l[3]), pair(l[4], l[5]), pair(l[6], l[7])
+		for _, tc := range []struct {
+			n    int
+			root [32]byte
+		}{
+			{1, l[0]},
+			{2, n01},
+			{3, pair(n01, pair(l[2], l[2]))},
+			{8, pair(pair(n01, n23), pair(n45, n67))},
+		} {
+			tree := v.tree()
+			for i := range tc.n {
+				tree.AddLeaf([]byte(fmt.Sprint("leaf", i)))
+			}
+			root := tree.Root()
+			if tree.Len() != tc.n || root != tc.root {
+				t.Fatalf("%s, %d leaves: Len, Root = %d, %x; want %d, %x", v.name, tc.n, tree.Len(), root, tc.n, tc.root)
+			}
+			if want, ok := v.roots[tc.n]; ok && hex.EncodeToString(root[:]) != want {
+				t.Errorf("%s, %d leaves: Root = %x, want known root %s", v.name, tc.n, root, want)
+			}
+			for i := range tc.n {
+				proof := tree.Proof(i)
+				if !v.verify(root, l[i], i, proof) {
+					t.Errorf("%s, %d leaves: proof for leaf %d does not verify", v.name, tc.n, i)
+				}
+				if v.verify(root, l[(i+1)%8], i, proof) {
+					t.Errorf("%s, %d leaves: proof for leaf %d verifies the wrong leaf", v.name, tc.n, i)
+				}
+				if i^1 < tc.n && v.verify(root, l[i], i^1, proof) {
+					t.Errorf("%s, %d leaves: proof for leaf %d verifies at index %d", v.name, tc.n, i, i^1)
+				}
+			}
+		}
+
+		if v.tree().Root() != ([32]byte{}) {
+			t.Fatalf("%s: empty tree root is not zero", v.name)
+		}
+	}
+}
+
+// TestMerkleTruncatedProof checks that a proof with its bottom sibling cut
+// off opens an interior node as a leaf in the plain tree, as MerkleTree
+// warns, but not in the prefixed one, whatever leaf data is tried.
+func TestMerkleTruncatedProof(t *testing.T) {
+	var plain MerkleTree
+	prefixed := NewPrefixedMerkleTree()
+	for i := range 4 {
+		plain.AddLeaf([]byte(fmt.Sprint("leaf", i)))
+		prefixed.AddLeaf([]byte(fmt.Sprint("leaf", i)))
+	}
+
+	l0, l1 := Sum256([]byte("leaf0")), Sum256([]byte("leaf1"))
+	if !VerifyMerkleProof(plain.Root(), HashPair(l0, l1), 0, plain.Proof(0)[1:]) {
+		t.Error("plain tree: truncated proof no longer opens the interior node")
+	}
+
+	root, proof := prefixed.Root(), prefixed.Proof(0)
+	p0, p1 := PrefixedMerkleLeaf([]byte("leaf0")), PrefixedMerkleLeaf([]byte("leaf1"))
+	if !VerifyPrefixedMerkleProof(root, p0, 0, proof) {
+		t.Fatal("prefixed tree: full proof does not verify")
+	}
+	for _, data := range [][]byte{
+		append(p0[:], p1[:]...),
+		append(append([]byte{0x01}, p0[:]...), p1[:]...),
+	} {
+		if VerifyPrefixedMerkleProof(root, PrefixedMerkleLeaf(data), 0, proof[1:]) {
+			t.Errorf("prefixed tree: truncated proof verifies leaf data %x", data)
+		}
+	}
+}
+
+func TestMerkleTreeProofPanics(t *testing.T) {
+	var tree MerkleTree
+	tree.AddLeaf([]byte("only"))
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Proof out of range did not panic")
+		}
+	}()
+	tree.Proof(1)
+}
+
+func BenchmarkHashPair(b *testing.B) {
+	a, c := Sum256([]byte("a")), Sum256([]byte("c"))
+	b.Run("HashPair", func(b *testing.B) {
+		b.SetBytes(64)
+		b.ReportAllocs()
+		for b.Loop() {
+			a = HashPair(a, c)
+		}
+	})
+	b.Run("Sum256", func(b *testing.B) {
+		var buf [64]byte
+		b.SetBytes(64)
+		b.ReportAllocs()
+		for b.Loop() {
+			copy(buf[:], a[:])
+			copy(buf[32:], c[:])
+			a = Sum256(buf[:])
+		}
+	})
+}
+
+func BenchmarkMerkleRoot(b *testing.B) {
+	var tree MerkleTree
+	leaf := make([]byte, 32)
+	for i := range 1024 {
+		leaf[0], leaf[1] = byte(i), byte(i>>8)
+		tree.AddLeaf(leaf)
+	}
+	b.SetBytes(1024 * 32)
+	b.ReportAllocs()
+	for b.Loop() {
+		tree.Root()
+	}
+}
@@ -0,0 +1,57 @@
+package keccak
+
+import "encoding/binary"
+
+// MessageBuilder absorbs a structured message field by field and hashes it
+// with Keccak-256, so signing schemes need not assemble the encoded bytes in
+// a temporary slice. Fields go straight into the sponge, and numeric fields
+// never allocate. The zero value is ready to use; it must not be used
+// concurrently.
+type MessageBuilder struct {
+	h Hasher
+}
+
+// Write absorbs p. It never returns an error.
+func (m *MessageBuilder) Write(p []byte) (int, error) {
+	return m.h.Write(p)
+}
+
+// WriteByte absorbs c. It never returns an error.
+func (m *MessageBuilder) WriteByte(c byte) error {
+	return m.h.WriteByte(c)
+}
+
+// WriteUint64LE absorbs the 8-byte little-endian encoding of v.
+func (m *MessageBuilder) WriteUint64LE(v uint64) {
+	m.h.WriteUint64(v)
+}
+
+// WriteUint64BE absorbs the 8-byte big-endian encoding of v.
+func (m *MessageBuilder) WriteUint64BE(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	m.h.Write(b[:])
+}
+
+// WriteUint256 absorbs the 32-byte big-endian encoding of the 256-bit
+// integer whose 64-bit limbs, least significant first, are v. That is the
+// layout of github.com/holiman/uint256.Int and the encoding of a uint256 in
+// the Ethereum ABI.
+func (m *MessageBuilder) WriteUint256(v [4]uint64) {
+	var b [32]byte
+	for i, limb := range v {
+		binary.BigEndian.PutUint64(b[24-8*i:], limb)
+	}
+	m.h.Write(b[:])
+}
+
+// Sum256 returns the Keccak-256 digest of the message so far. Does not
+// modify the builder, so more fields may follow.
+func (m *MessageBuilder) Sum256() [32]byte {
+	return m.h.Sum256()
+}
+
+// Reset discards the message.
+func (m *MessageBuilder) Reset() {
+	m.h.Reset()
+}
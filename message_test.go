@@ -0,0 +1,48 @@
+package keccak
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestMessageBuilder(t *testing.T) {
+	var m MessageBuilder
+	m.WriteByte(0x19)
+	m.Write([]byte("header"))
+	m.WriteUint64LE(0x0102030405060708)
+	m.WriteUint64BE(0x0102030405060708)
+	m.WriteUint256([4]uint64{1, 2, 3, 4})
+	m.Write(make([]byte, 2*rate))
+
+	var want []byte
+	want = append(want, 0x19)
+	want = append(want, "header"...)
+	want = binary.LittleEndian.AppendUint64(want, 0x0102030405060708)
+	want = binary.BigEndian.AppendUint64(want, 0x0102030405060708)
+	for _, limb := range []uint64{4, 3, 2, 1} {
+		want = binary.BigEndian.AppendUint64(want, limb)
+	}
+	want = append(want, make([]byte, 2*rate)...)
+	if got := m.Sum256(); got != Sum256(want) {
+		t.Fatalf("MessageBuilder = %x, want %x", got, Sum256(want))
+	}
+
+	// A uint256 of 1 encodes as 31 zero bytes and a one.
+	m.Reset()
+	m.WriteUint256([4]uint64{1})
+	one := make([]byte, 32)
+	one[31] = 1
+	if got := m.Sum256(); got != Sum256(one) {
+		t.Fatalf("WriteUint256(1) = %x, want %x", got, Sum256(one))
+	}
+
+	n := testing.AllocsPerRun(100, func() {
+		m.WriteByte(1)
+		m.WriteUint64LE(2)
+		m.WriteUint64BE(3)
+		m.WriteUint256([4]uint64{4, 5, 6, 7})
+	})
+	if n != 0 {
+		t.Fatalf("numeric writes allocated %v times", n)
+	}
+}
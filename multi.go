@@ -0,0 +1,121 @@
+package keccak
+
+// Variant identifies a Keccak-family hash computed by a MultiHasher.
+type Variant uint8
+
+const (
+	Keccak256 Variant = iota + 1 // legacy Keccak-256, as used by Ethereum
+	Keccak512                    // legacy Keccak-512
+	SHA3_256                     // FIPS 202 SHA3-256
+	SHA3_512                     // FIPS 202 SHA3-512
+)
+
+// params returns the sponge rate and domain separation byte of v.
+func (v Variant) params() (rate int, domain byte) {
+	switch v {
+	case Keccak256:
+		return 136, 0x01
+	case Keccak512:
+		return 72, 0x01
+	case SHA3_256:
+		return 136, 0x06
+	case SHA3_512:
+		return 72, 0x06
+	}
+	panic("keccak: unknown Variant")
+}
+
+// Size returns the digest size of v in bytes.
+func (v Variant) Size() int {
+	rate, _ := v.params()
+	return (200 - rate) / 2
+}
+
+// BlockSize returns the sponge rate of v in bytes.
+func (v Variant) BlockSize() int {
+	rate, _ := v.params()
+	return rate
+}
+
+// multiChunk is how much input each variant absorbs before the next one
+// sees it, keeping the shared chunk hot in cache.
+const multiChunk = 4096
+
+// MultiHasher hashes one stream into several Keccak variants at once. Each
+// variant keeps its own sponge, so variants with different rates can share a
+// single Write. A MultiHasher must not be used concurrently.
+type MultiHasher struct {
+	variants []Variant
+	sponges  []keccakSponge
+}
+
+// NewMultiHasher returns a MultiHasher computing each of variants. It keeps
+// its own copy of the list, so the caller may reuse the slice.
+// Panics if a variant is unknown or repeated.
+func NewMultiHasher(variants ...Variant) *MultiHasher {
+	variants = append([]Variant(nil), variants...)
+	m := &MultiHasher{
+		variants: variants,
+		sponges:  make([]keccakSponge, len(variants)),
+	}
+	for i, v := range variants {
+		for _, w := range variants[:i] {
+			if v == w {
+				panic("keccak: duplicate MultiHasher variant")
+			}
+		}
+		m.sponges[i].rate, m.sponges[i].domain = v.params()
+	}
+	return m
+}
+
+// Reset resets every variant to its initial state.
+func (m *MultiHasher) Reset() {
+	for i := range m.sponges {
+		m.sponges[i].reset()
+	}
+}
+
+// Write absorbs p into every variant. It never returns an error.
+func (m *MultiHasher) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		c := p[:min(len(p), multiChunk)]
+		for i := range m.sponges {
+			m.sponges[i].absorb(c)
+		}
+		p = p[len(c):]
+	}
+	return n, nil
+}
+
+// Sum256 returns the digest of v, which must be a 256-bit variant.
+// Does not modify the hasher state.
+func (m *MultiHasher) Sum256(v Variant) [32]byte {
+	var out [32]byte
+	m.sum(v, out[:])
+	return out
+}
+
+// Sum512 returns the digest of v, which must be a 512-bit variant.
+// Does not modify the hasher state.
+func (m *MultiHasher) Sum512(v Variant) [64]byte {
+	var out [64]byte
+	m.sum(v, out[:])
+	return out
+}
+
+func (m *MultiHasher) sum(v Variant, out []byte) {
+	for i, w := range m.variants {
+		if w != v {
+			continue
+		}
+		s := m.sponges[i]
+		if v.Size() != len(out) {
+			panic("keccak: MultiHasher digest size does not match variant")
+		}
+		s.squeeze(out)
+		return
+	}
+	panic("keccak: variant not computed by this MultiHasher")
+}
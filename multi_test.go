@@ -0,0 +1,108 @@
+package keccak
+
+import (
+	"hash"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestMultiHasher(t *testing.T) {
+	data := make([]byte, 3*multiChunk+555)
+	for i := range data {
+		data[i] = byte(i * 11)
+	}
+	m := NewMultiHasher(Keccak256, SHA3_256, Keccak512, SHA3_512)
+	// Odd write sizes straddle both rates and the internal chunking.
+	for p := data; len(p) > 0; {
+		n := min(len(p), 97)
+		m.Write(p[:n])
+		p = p[n:]
+	}
+
+	if got, want := m.Sum256(Keccak256), Sum256(data); got != want {
+		t.Errorf("Keccak256 = %x, want %x", got, want)
+	}
+	if got, want := m.Sum256(SHA3_256), sha3.Sum256(data); got != want {
+		t.Errorf("SHA3_256 = %x, want %x", got, want)
+	}
+	k512 := sha3.NewLegacyKeccak512()
+	k512.Write(data)
+	if got, want := m.Sum512(Keccak512), k512.Sum(nil); string(got[:]) != string(want) {
+		t.Errorf("Keccak512 = %x, want %x", got, want)
+	}
+	if got, want := m.Sum512(SHA3_512), sha3.Sum512(data); got != want {
+		t.Errorf("SHA3_512 = %x, want %x", got, want)
+	}
+
+	// Sums are non-destructive and Reset starts over.
+	if m.Sum256(Keccak256) != Sum256(data) {
+		t.Error("second Sum256 differs")
+	}
+	m.Reset()
+	m.Write([]byte("abc"))
+	if got, want := m.Sum256(SHA3_256), sha3.Sum256([]byte("abc")); got != want {
+		t.Errorf("SHA3_256 after Reset = %x, want %x", got, want)
+	}
+}
+
+func TestMultiHasherCopiesVariants(t *testing.T) {
+	variants := []Variant{Keccak256, SHA3_256}
+	m := NewMultiHasher(variants...)
+	variants[0], variants[1] = SHA3_256, Keccak256
+	m.Write([]byte("abc"))
+	if got, want := m.Sum256(Keccak256), Sum256([]byte("abc")); got != want {
+		t.Errorf("Keccak256 after mutating the argument = %x, want %x", got, want)
+	}
+	if got, want := m.Sum256(SHA3_256), sha3.Sum256([]byte("abc")); got != want {
+		t.Errorf("SHA3_256 after mutating the argument = %x, want %x", got, want)
+	}
+}
+
+func TestVariantSizes(t *testing.T) {
+	for _, tc := range []struct {
+		v   Variant
+		ref hash.Hash
+	}{
+		{Keccak256, sha3.NewLegacyKeccak256()},
+		{Keccak512, sha3.NewLegacyKeccak512()},
+		{SHA3_256, sha3.New256()},
+		{SHA3_512, sha3.New512()},
+	} {
+		if tc.v.Size() != tc.ref.Size() || tc.v.BlockSize() != tc.ref.BlockSize() {
+			t.Errorf("variant %d: Size, BlockSize = %d, %d; want %d, %d",
+				tc.v, tc.v.Size(), tc.v.BlockSize(), tc.ref.Size(), tc.ref.BlockSize())
+		}
+	}
+}
+
+func TestMultiHasherPanics(t *testing.T) {
+	for name, f := range map[string]func(){
+		"missing":   func() { NewMultiHasher(Keccak256).Sum256(SHA3_256) },
+		"size":      func() { NewMultiHasher(Keccak512).Sum256(Keccak512) },
+		"duplicate": func() { NewMultiHasher(Keccak256, Keccak256) },
+		"unknown":   func() { NewMultiHasher(Variant(0)) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func BenchmarkMultiHasher(b *testing.B) {
+	data := make([]byte, 16<<10)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	m := NewMultiHasher(Keccak256, SHA3_256)
+	for b.Loop() {
+		m.Reset()
+		m.Write(data)
+		m.Sum256(Keccak256)
+		m.Sum256(SHA3_256)
+	}
+}
@@ -0,0 +1,28 @@
+package keccak
+
+import (
+	"runtime"
+	"sync"
+)
+
+// parallelFor splits [0, n) into at most GOMAXPROCS contiguous ranges and
+// calls f on each concurrently, returning when all calls have finished.
+func parallelFor(n int, f func(lo, hi int)) {
+	workers := min(runtime.GOMAXPROCS(0), n)
+	if workers <= 1 {
+		f(0, n)
+		return
+	}
+	per := (n + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += per {
+		hi := min(lo+per, n)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f(lo, hi)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,38 @@
+package keccak
+
+import "sync"
+
+var hasherPool = sync.Pool{
+	New: func() any { return new(Hasher) },
+}
+
+// GetHasher returns a reset Hasher from a shared pool.
+// Return it with PutHasher when done.
+func GetHasher() *Hasher {
+	h := hasherPool.Get().(*Hasher)
+	h.Reset()
+	return h
+}
+
+// PutHasher resets h and returns it to the shared pool.
+// h must not be used after PutHasher returns.
+func PutHasher(h *Hasher) {
+	h.Reset()
+	hasherPool.Put(h)
+}
+
+var statePool = sync.Pool{
+	New: func() any { return new([200]byte) },
+}
+
+// Sum256Pooled computes the Keccak-256 hash of data on a sponge state
+// borrowed from a shared pool. It never allocates per call, even where escape
+// analysis would move Sum256's local state to the heap, and it is safe for
+// concurrent use. The state is zeroed before it goes back to the pool.
+func Sum256Pooled(data []byte) [32]byte {
+	s := statePool.Get().(*[200]byte)
+	d := sum256State(s, data)
+	*s = [200]byte{}
+	statePool.Put(s)
+	return d
+}
@@ -0,0 +1,149 @@
+package keccak
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPooledHasherReuse(t *testing.T) {
+	inputs := [][]byte{[]byte("first"), nil, make([]byte, rate*2+9), []byte("last")}
+	for i := 0; i < 3; i++ {
+		for _, in := range inputs {
+			h := GetHasher()
+			h.Write(in)
+			got := h.Sum256()
+			PutHasher(h)
+			if want := Sum256(in); got != want {
+				t.Fatalf("pooled hasher len=%d: got %x, want %x", len(in), got, want)
+			}
+		}
+	}
+
+	// A hasher returned mid-squeeze must come back writable.
+	h := GetHasher()
+	h.Write([]byte("data"))
+	h.Read(make([]byte, 32))
+	PutHasher(h)
+	h = GetHasher()
+	h.Write([]byte("second"))
+	if got, want := h.Sum256(), Sum256([]byte("second")); got != want {
+		t.Fatalf("after squeezing reuse: got %x, want %x", got, want)
+	}
+	PutHasher(h)
+}
+
+func TestPooledHasherConcurrent(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	want := Sum256(data)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				h := GetHasher()
+				h.Write(data)
+				got := h.Sum256()
+				PutHasher(h)
+				if got != want {
+					t.Errorf("concurrent pooled hasher: got %x, want %x", got, want)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSum256PooledConcurrent(t *testing.T) {
+	inputs := make([][]byte, 16)
+	for i := range inputs {
+		inputs[i] = make([]byte, i*37)
+		for j := range inputs[i] {
+			inputs[i][j] = byte(i + j)
+		}
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				in := inputs[(g+i)%len(inputs)]
+				if got, want := Sum256Pooled(in), Sum256(in); got != want {
+					t.Errorf("Sum256Pooled len=%d: got %x, want %x", len(in), got, want)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	data := inputs[5]
+	if n := testing.AllocsPerRun(100, func() { Sum256Pooled(data) }); n != 0 {
+		t.Fatalf("Sum256Pooled allocated %v times", n)
+	}
+}
+
+// hasherSink forces benchmarked hashers to escape, as they do when passed around.
+var hasherSink atomic.Pointer[Hasher]
+
+func BenchmarkHasherPoolParallel(b *testing.B) {
+	data := make([]byte, 128)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	b.Run("New", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				h := NewFastKeccak()
+				h.Write(data)
+				h.Sum256()
+				hasherSink.Store(h)
+			}
+		})
+	})
+	b.Run("Pool", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				h := GetHasher()
+				h.Write(data)
+				h.Sum256()
+				PutHasher(h)
+			}
+		})
+	})
+}
+
+func BenchmarkSum256PooledParallel(b *testing.B) {
+	data := make([]byte, 128)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	b.Run("Sum256", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				Sum256(data)
+			}
+		})
+	})
+	b.Run("Pooled", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				Sum256Pooled(data)
+			}
+		})
+	})
+}
@@ -0,0 +1,40 @@
+package keccak
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Rand is a deterministic byte stream: the SHAKE256 output (the Keccak-256
+// rate with XOF domain byte 0x1F) of a seed. The same seed always yields the
+// same stream, which suits reproducible tests and simulations. It is not a
+// substitute for crypto/rand when unpredictability is needed, since anyone
+// who knows the seed can recompute the stream. A Rand must not be used
+// concurrently.
+type Rand struct {
+	s keccakSponge
+}
+
+var _ io.Reader = (*Rand)(nil)
+
+// NewRand returns a Rand whose stream is SHAKE256(seed).
+func NewRand(seed []byte) *Rand {
+	r := &Rand{s: newShake256()}
+	r.s.absorb(seed)
+	return r
+}
+
+// Read fills p with the next len(p) bytes of the stream. It never returns
+// an error.
+func (r *Rand) Read(p []byte) (int, error) {
+	r.s.squeeze(p)
+	return len(p), nil
+}
+
+// Uint64 returns the next 8 bytes of the stream as a little-endian integer,
+// so a Rand can serve as a math/rand/v2 Source.
+func (r *Rand) Uint64() uint64 {
+	var b [8]byte
+	r.s.squeeze(b[:])
+	return binary.LittleEndian.Uint64(b[:])
+}
@@ -0,0 +1,50 @@
+package keccak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand/v2"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestRand(t *testing.T) {
+	seed := []byte("seed")
+	// Uneven reads, including empty ones, see one continuous stream.
+	r := NewRand(seed)
+	var got []byte
+	for _, n := range []int{0, 1, rate - 1, 0, rate + 3, rate + 1} {
+		buf := make([]byte, n)
+		if m, err := r.Read(buf); m != n || err != nil {
+			t.Fatalf("Read(%d) = %d, %v", n, m, err)
+		}
+		got = append(got, buf...)
+	}
+	want := make([]byte, len(got))
+	sha3.ShakeSum256(want, seed)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("stream = %x, want SHAKE256(seed) = %x", got, want)
+	}
+
+	a, b := make([]byte, 64), make([]byte, 64)
+	NewRand(seed).Read(a)
+	NewRand(seed).Read(b)
+	if !bytes.Equal(a, b) {
+		t.Fatal("same seed gave different streams")
+	}
+	NewRand([]byte("seed2")).Read(b)
+	if bytes.Equal(a, b) {
+		t.Fatal("different seeds gave the same stream")
+	}
+
+	if got := NewRand(seed).Uint64(); got != binary.LittleEndian.Uint64(want) {
+		t.Fatalf("Uint64 = %#x, want %#x", got, binary.LittleEndian.Uint64(want))
+	}
+	var _ rand.Source = NewRand(seed)
+
+	buf := make([]byte, 100)
+	if n := testing.AllocsPerRun(100, func() { r.Read(buf); r.Uint64() }); n != 0 {
+		t.Fatalf("Read and Uint64 allocated %v times", n)
+	}
+}
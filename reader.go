@@ -0,0 +1,175 @@
+package keccak
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// readBufSize is the chunk size used when absorbing from an io.Reader.
+// It is a multiple of rate so full chunks never hit the carry buffer.
+const readBufSize = 32 * rate // 4352 bytes
+
+// readBufPool holds the read buffers of ReadFrom and the Sum functions. A
+// buffer handed to an io.Reader escapes to the heap, so a local array would
+// cost an allocation on every call.
+var readBufPool = sync.Pool{
+	New: func() any { return new([readBufSize]byte) },
+}
+
+var _ io.ReaderFrom = (*Hasher)(nil)
+
+// ReadFrom absorbs data from r until EOF, so that io.Copy(&h, r) works.
+// It returns the number of bytes absorbed and the first read error other than io.EOF.
+// Panics if called after Read.
+// The read buffer comes from a shared pool, so ReadFrom does not allocate.
+func (h *Hasher) ReadFrom(r io.Reader) (int64, error) {
+	buf := readBufPool.Get().(*[readBufSize]byte)
+	defer readBufPool.Put(buf)
+	return h.readFrom(context.Background(), r, buf[:])
+}
+
+// WriteAll absorbs data from r until EOF, reading through buf, which the
+// caller owns and may reuse; WriteAll itself never allocates. Any non-empty
+// buf works, but a multiple of 136 bytes (the rate) avoids copying through
+// the carry buffer. It returns the number of bytes absorbed and the first
+// read error other than io.EOF.
+// Panics if buf is empty or if called after Read.
+func (h *Hasher) WriteAll(r io.Reader, buf []byte) (int64, error) {
+	if len(buf) == 0 {
+		panic("keccak: WriteAll with empty buffer")
+	}
+	return h.readFrom(context.Background(), r, buf)
+}
+
+// WriteSumTo writes the 32-byte Keccak-256 digest of everything written so
+// far to w, returning the bytes written and any error from w. Like Sum256 it
+// does not modify the hasher state. The digest escapes through w, so the
+// call allocates 32 bytes unless w is known to the compiler.
+func (h *Hasher) WriteSumTo(w io.Writer) (int, error) {
+	d := h.Sum256()
+	return w.Write(d[:])
+}
+
+// readFrom implements ReadFrom and WriteAll. It checks ctx before every call
+// to r.Read, so a reader that returns short reads cannot fill a whole buffer
+// past a cancellation; the check is cheap next to the read itself.
+func (h *Hasher) readFrom(ctx context.Context, r io.Reader, buf []byte) (int64, error) {
+	var total int64
+	n := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		m, err := r.Read(buf[n:])
+		n += m
+		total += int64(m)
+		if n == len(buf) {
+			h.Write(buf)
+			n = 0
+		}
+		if err != nil {
+			h.Write(buf[:n])
+			if err == io.EOF {
+				err = nil
+			}
+			return total, err
+		}
+	}
+}
+
+// SumReader computes the Keccak-256 hash of everything read from r until EOF.
+// It returns the digest and the first read error other than io.EOF.
+func SumReader(r io.Reader) ([32]byte, error) {
+	var h Hasher
+	if _, err := h.ReadFrom(r); err != nil {
+		return [32]byte{}, err
+	}
+	return h.Sum256(), nil
+}
+
+// SumReaderContext is like SumReader but stops early, returning ctx.Err(),
+// once ctx is done. The context is checked before every read of r, so a
+// cancellation takes effect within one read, however short r's reads are.
+func SumReaderContext(ctx context.Context, r io.Reader) ([32]byte, error) {
+	var h Hasher
+	buf := readBufPool.Get().(*[readBufSize]byte)
+	defer readBufPool.Put(buf)
+	if _, err := h.readFrom(ctx, r, buf[:]); err != nil {
+		return [32]byte{}, err
+	}
+	return h.Sum256(), nil
+}
+
+// CopyHash copies src to dst until EOF, like io.Copy, and returns the
+// Keccak-256 hash of the copied bytes with the number of bytes written. A
+// write that reports fewer bytes than given fails with io.ErrShortWrite. On
+// any error the digest is zero and the count covers what dst accepted. Every
+// byte passes through one buffer borrowed from a shared pool, so src's
+// WriterTo and dst's ReaderFrom are never used and CopyHash does not
+// allocate.
+func CopyHash(dst io.Writer, src io.Reader) ([32]byte, int64, error) {
+	var h Hasher
+	buf := readBufPool.Get().(*[readBufSize]byte)
+	defer readBufPool.Put(buf)
+	var written int64
+	for {
+		n, err := src.Read(buf[:])
+		if n > 0 {
+			m, werr := dst.Write(buf[:n])
+			written += int64(m)
+			if werr == nil && m != n {
+				werr = io.ErrShortWrite
+			}
+			if werr != nil {
+				return [32]byte{}, written, werr
+			}
+			h.Write(buf[:n])
+		}
+		if err == io.EOF {
+			return h.Sum256(), written, nil
+		}
+		if err != nil {
+			return [32]byte{}, written, err
+		}
+	}
+}
+
+// SumFile computes the Keccak-256 hash of the file at path. If progress is
+// not nil, it is called after every chunk of a few kilobytes with the bytes
+// hashed so far and the file size when it was opened. A file that changes
+// size while being read is hashed up to whatever EOF the reads reach, so
+// done can end below or above total. The file is always closed.
+func SumFile(path string, progress func(done, total int64)) ([32]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	total := fi.Size()
+
+	var h Hasher
+	buf := readBufPool.Get().(*[readBufSize]byte)
+	defer readBufPool.Put(buf)
+	var done int64
+	for {
+		// Full chunks are whole blocks, so only the last one is buffered.
+		n, err := io.ReadFull(f, buf[:])
+		h.Write(buf[:n])
+		done += int64(n)
+		if progress != nil && n > 0 {
+			progress(done, total)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return h.Sum256(), nil
+		}
+		if err != nil {
+			return [32]byte{}, err
+		}
+	}
+}
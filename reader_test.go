@@ -0,0 +1,434 @@
+package keccak
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSumReaderFile(t *testing.T) {
+	for _, size := range []int{0, 1, rate - 1, rate, readBufSize - 1, readBufSize, readBufSize + 1, 3*readBufSize + 77} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i * 13)
+		}
+		path := filepath.Join(t.TempDir(), "data")
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := SumReader(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("size=%d: SumReader error: %v", size, err)
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := Sum256(contents); got != want {
+			t.Fatalf("size=%d: SumReader = %x, want %x", size, got, want)
+		}
+	}
+}
+
+func TestSumFile(t *testing.T) {
+	for _, size := range []int{0, 1, rate, readBufSize - 1, readBufSize, 3*readBufSize + 77} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i * 31)
+		}
+		path := filepath.Join(t.TempDir(), "data")
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		var calls int
+		var last int64
+		got, err := SumFile(path, func(done, total int64) {
+			calls++
+			if done <= last || done > total || total != int64(size) {
+				t.Errorf("size=%d: progress(%d, %d) after %d", size, done, total, last)
+			}
+			last = done
+		})
+		if err != nil {
+			t.Fatalf("size=%d: SumFile error: %v", size, err)
+		}
+		if want := Sum256(data); got != want {
+			t.Fatalf("size=%d: SumFile = %x, want %x", size, got, want)
+		}
+		if wantCalls := (size + readBufSize - 1) / readBufSize; calls != wantCalls || last != int64(size) {
+			t.Errorf("size=%d: %d progress calls ending at %d, want %d ending at %d", size, calls, last, wantCalls, size)
+		}
+
+		if got, err := SumFile(path, nil); err != nil || got != Sum256(data) {
+			t.Errorf("size=%d: SumFile without progress = %x, %v", size, got, err)
+		}
+	}
+}
+
+func TestSumFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := SumFile(filepath.Join(dir, "missing"), nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: got %v, want ErrNotExist", err)
+	}
+	// Reading a directory fails after it is opened.
+	if _, err := SumFile(dir, nil); err == nil {
+		t.Error("directory: got nil error")
+	}
+}
+
+func TestSumReaderShortReads(t *testing.T) {
+	data := make([]byte, 2*readBufSize+500)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	want := Sum256(data)
+	for _, tc := range []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+	}{
+		{"OneByteReader", iotest.OneByteReader},
+		{"HalfReader", iotest.HalfReader},
+		{"DataErrReader", iotest.DataErrReader},
+	} {
+		got, err := SumReader(tc.wrap(bytes.NewReader(data)))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if got != want {
+			t.Fatalf("%s: SumReader = %x, want %x", tc.name, got, want)
+		}
+	}
+}
+
+func TestSumReaderError(t *testing.T) {
+	errBoom := errors.New("boom")
+	if _, err := SumReader(iotest.ErrReader(errBoom)); err != errBoom {
+		t.Fatalf("SumReader error = %v, want %v", err, errBoom)
+	}
+}
+
+func TestReadFromMatchesWrite(t *testing.T) {
+	data := make([]byte, 5*readBufSize+123)
+	for i := range data {
+		data[i] = byte(i * 31)
+	}
+
+	var want Hasher
+	for i := 0; i < len(data); i += 100 {
+		want.Write(data[i:min(i+100, len(data))])
+	}
+
+	var h Hasher
+	n, err := h.ReadFrom(iotest.HalfReader(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatalf("ReadFrom error: %v", err)
+	}
+	if n != int64(len(data)) {
+		t.Fatalf("ReadFrom n = %d, want %d", n, len(data))
+	}
+	if got := h.Sum256(); got != want.Sum256() {
+		t.Fatalf("ReadFrom digest = %x, want %x", got, want.Sum256())
+	}
+
+	var hc Hasher
+	n, err = io.Copy(&hc, iotest.OneByteReader(bytes.NewReader(data)))
+	if err != nil || n != int64(len(data)) {
+		t.Fatalf("io.Copy = (%d, %v), want (%d, nil)", n, err, len(data))
+	}
+	if got := hc.Sum256(); got != want.Sum256() {
+		t.Fatalf("io.Copy digest = %x, want %x", got, want.Sum256())
+	}
+}
+
+// cancelingReader yields endless data and cancels its context once limit
+// bytes have been read.
+type cancelingReader struct {
+	n, limit int
+	cancel   context.CancelFunc
+}
+
+func (r *cancelingReader) Read(p []byte) (int, error) {
+	if r.n >= r.limit {
+		r.cancel()
+	}
+	r.n += len(p)
+	return len(p), nil
+}
+
+func TestSumReaderContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &cancelingReader{limit: 1 << 20, cancel: cancel}
+	_, err := SumReaderContext(ctx, r)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	// The reader is endless, so returning at all shows the check works;
+	// it must also come within one buffer of the cancellation.
+	if over := r.n - r.limit; over > 2*readBufSize {
+		t.Fatalf("read %d bytes past cancellation", over)
+	}
+}
+
+// shortCancelingReader returns size bytes per read and cancels once limit
+// bytes have been read, counting the reads that follow.
+type shortCancelingReader struct {
+	size, limit, n int
+	after          int
+	cancel         context.CancelFunc
+}
+
+func (r *shortCancelingReader) Read(p []byte) (int, error) {
+	if r.n >= r.limit {
+		r.after++
+		r.cancel()
+	}
+	m := min(r.size, len(p))
+	r.n += m
+	return m, nil
+}
+
+func TestSumReaderContextCancelShortReads(t *testing.T) {
+	// Cancel partway through the first buffer: with 7-byte reads, a check
+	// made only when a buffer starts would read on to readBufSize.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &shortCancelingReader{size: 7, limit: 1000, cancel: cancel}
+	if _, err := SumReaderContext(ctx, r); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if r.after != 1 {
+		t.Fatalf("%d reads after reaching the limit, want 1 (the one that cancels)", r.after)
+	}
+}
+
+func TestSumReaderContext(t *testing.T) {
+	data := make([]byte, 3*readBufSize+10)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	got, err := SumReaderContext(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Sum256(data); got != want {
+		t.Fatalf("SumReaderContext = %x, want %x", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := SumReaderContext(ctx, iotest.ErrReader(errors.New("read called"))); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled context: err = %v, want context.Canceled", err)
+	}
+}
+
+func TestHasherMultiWriterTee(t *testing.T) {
+	data := make([]byte, 5*readBufSize+123)
+	for i := range data {
+		data[i] = byte(i * 31)
+	}
+	var h Hasher
+	var copied bytes.Buffer
+	n, err := io.Copy(io.MultiWriter(&copied, &h), iotest.HalfReader(bytes.NewReader(data)))
+	if err != nil || n != int64(len(data)) {
+		t.Fatalf("io.Copy = %d, %v; want %d, nil", n, err, len(data))
+	}
+	if !bytes.Equal(copied.Bytes(), data) {
+		t.Fatal("tee'd copy differs from the source")
+	}
+	if got, want := h.Sum256(), Sum256(data); got != want {
+		t.Fatalf("tee'd digest = %x, want %x", got, want)
+	}
+
+	var w io.Writer = &h
+	if n, err := w.Write(data[:77]); n != 77 || err != nil {
+		t.Fatalf("Write = %d, %v; want 77, nil", n, err)
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+	data := make([]byte, 5*readBufSize+123)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	want := Sum256(data)
+	for _, size := range []int{1, 100, rate, 3 * rate, readBufSize, readBufSize + 1} {
+		buf := make([]byte, size)
+		var h Hasher
+		n, err := h.WriteAll(iotest.HalfReader(bytes.NewReader(data)), buf)
+		if err != nil || n != int64(len(data)) {
+			t.Fatalf("buf=%d: WriteAll = %d, %v; want %d, nil", size, n, err, len(data))
+		}
+		if got := h.Sum256(); got != want {
+			t.Fatalf("buf=%d: WriteAll digest = %x, want %x", size, got, want)
+		}
+	}
+
+	errBoom := errors.New("boom")
+	var h Hasher
+	if _, err := h.WriteAll(iotest.ErrReader(errBoom), make([]byte, rate)); err != errBoom {
+		t.Fatalf("WriteAll error = %v, want %v", err, errBoom)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("WriteAll with an empty buffer did not panic")
+		}
+	}()
+	h.WriteAll(bytes.NewReader(data), nil)
+}
+
+func TestWriteAllNoAllocs(t *testing.T) {
+	data := make([]byte, 3*readBufSize+10)
+	buf := make([]byte, 4*rate)
+	r := bytes.NewReader(data)
+	var h Hasher
+	n := testing.AllocsPerRun(100, func() {
+		r.Reset(data)
+		h.Reset()
+		h.WriteAll(r, buf)
+	})
+	if n != 0 {
+		t.Fatalf("WriteAll allocated %v times", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { h.Write(buf[:77]) }); n != 0 {
+		t.Fatalf("Write allocated %v times", n)
+	}
+}
+
+func TestSumReaderNoAllocs(t *testing.T) {
+	data := make([]byte, 3*readBufSize+10)
+	r := bytes.NewReader(data)
+	ctx := context.Background()
+	var h Hasher
+	for name, f := range map[string]func(){
+		"ReadFrom":         func() { h.Reset(); h.ReadFrom(r) },
+		"SumReader":        func() { SumReader(r) },
+		"SumReaderContext": func() { SumReaderContext(ctx, r) },
+		"CopyHash":         func() { CopyHash(io.Discard, r) },
+	} {
+		if n := testing.AllocsPerRun(100, func() { r.Reset(data); f() }); n != 0 {
+			t.Errorf("%s allocated %v times", name, n)
+		}
+	}
+}
+
+func BenchmarkWriteAll(b *testing.B) {
+	data := make([]byte, 64<<10)
+	buf := make([]byte, 32*rate)
+	r := bytes.NewReader(data)
+	var h Hasher
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		r.Reset(data)
+		h.Reset()
+		h.WriteAll(r, buf)
+	}
+}
+
+func BenchmarkHasherWriteReusedBuffer(b *testing.B) {
+	buf := make([]byte, 1500) // a typical packet-sized read
+	var h Hasher
+	b.SetBytes(int64(len(buf)))
+	b.ReportAllocs()
+	for b.Loop() {
+		h.Write(buf)
+	}
+}
+
+func TestWriteSumTo(t *testing.T) {
+	for _, n := range []int{0, 1, rate - 1, rate, 3*rate + 7} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i * 5)
+		}
+		var h Hasher
+		h.Write(data)
+		var buf bytes.Buffer
+		buf.WriteString("frame:")
+		m, err := h.WriteSumTo(&buf)
+		if err != nil || m != Size {
+			t.Fatalf("len=%d: WriteSumTo = %d, %v; want %d, nil", n, m, err, Size)
+		}
+		want := Sum256(data)
+		if got := buf.Bytes(); string(got) != "frame:"+string(want[:]) {
+			t.Fatalf("len=%d: wrote %x, want frame:%x", n, got, want)
+		}
+		// The hasher is left able to absorb more input.
+		h.Write([]byte("more"))
+		if got, want := h.Sum256(), Sum256(append(data, "more"...)); got != want {
+			t.Fatalf("len=%d: after WriteSumTo got %x, want %x", n, got, want)
+		}
+	}
+}
+
+func TestWriteSumToError(t *testing.T) {
+	var h Hasher
+	w := &errWriter{err: errors.New("closed")}
+	if n, err := h.WriteSumTo(w); n != 0 || err != w.err {
+		t.Fatalf("WriteSumTo = %d, %v; want 0, %v", n, err, w.err)
+	}
+}
+
+type errWriter struct{ err error }
+
+func (w *errWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestCopyHash(t *testing.T) {
+	for _, size := range []int{0, 1, rate, readBufSize, 5*readBufSize + 123} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		var dst bytes.Buffer
+		sum, n, err := CopyHash(&dst, iotest.HalfReader(bytes.NewReader(data)))
+		if err != nil || n != int64(size) {
+			t.Fatalf("size=%d: CopyHash = %d, %v; want %d, nil", size, n, err, size)
+		}
+		if !bytes.Equal(dst.Bytes(), data) {
+			t.Fatalf("size=%d: dst differs from the source", size)
+		}
+		if want := Sum256(data); sum != want {
+			t.Fatalf("size=%d: CopyHash digest = %x, want %x", size, sum, want)
+		}
+	}
+}
+
+type shortWriter struct{}
+
+func (shortWriter) Write(p []byte) (int, error) { return len(p) / 2, nil }
+
+func TestCopyHashErrors(t *testing.T) {
+	data := make([]byte, 1000)
+	readErr := errors.New("read failed")
+	for _, tc := range []struct {
+		name    string
+		dst     io.Writer
+		src     io.Reader
+		want    error
+		written int64
+	}{
+		{"read", io.Discard, io.MultiReader(bytes.NewReader(data), iotest.ErrReader(readErr)), readErr, 1000},
+		{"write", &errWriter{err: io.ErrClosedPipe}, bytes.NewReader(data), io.ErrClosedPipe, 0},
+		{"short write", shortWriter{}, bytes.NewReader(data), io.ErrShortWrite, 500},
+	} {
+		sum, n, err := CopyHash(tc.dst, tc.src)
+		if err != tc.want || n != tc.written || sum != ([32]byte{}) {
+			t.Errorf("%s: CopyHash = %x, %d, %v; want zero, %d, %v", tc.name, sum, n, err, tc.written, tc.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package keccak
+
+import "sync"
+
+var _ KeccakState = (*SafeHasher)(nil)
+
+// SafeHasher is a Hasher guarded by a mutex, safe for concurrent use by
+// multiple goroutines. Each method call is atomic with respect to the others,
+// so concurrent Writes are absorbed whole, in some order.
+// Prefer a Hasher per goroutine where possible; the lock costs throughput.
+type SafeHasher struct {
+	mu sync.Mutex
+	h  Hasher
+}
+
+// Reset resets the hasher to its initial state.
+func (s *SafeHasher) Reset() {
+	s.mu.Lock()
+	s.h.Reset()
+	s.mu.Unlock()
+}
+
+// Write absorbs data into the hasher.
+// Panics if called after Read.
+func (s *SafeHasher) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.h.Write(p)
+}
+
+// Sum256 finalizes and returns the 32-byte Keccak-256 digest.
+// Does not modify the hasher state.
+func (s *SafeHasher) Sum256() [32]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.h.Sum256()
+}
+
+// Sum appends the current Keccak-256 digest to b and returns the resulting slice.
+// Does not modify the hasher state.
+func (s *SafeHasher) Sum(b []byte) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.h.Sum(b)
+}
+
+// Read squeezes an arbitrary number of bytes from the sponge.
+// Subsequent calls to Write will panic. It never returns an error.
+func (s *SafeHasher) Read(out []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.h.Read(out)
+}
+
+// Size returns the number of bytes Sum will produce (32).
+func (s *SafeHasher) Size() int { return Size }
+
+// BlockSize returns the sponge rate in bytes (136).
+func (s *SafeHasher) BlockSize() int { return BlockSize }
@@ -0,0 +1,35 @@
+package keccak
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestSafeHasherConcurrentWrites(t *testing.T) {
+	// Every goroutine writes the same chunk, so the result does not depend on
+	// the order in which writes are serialized.
+	chunk := make([]byte, 100)
+	for i := range chunk {
+		chunk[i] = byte(i)
+	}
+	const goroutines, writes = 8, 50
+
+	var s SafeHasher
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range writes {
+				s.Write(chunk)
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := Sum256(bytes.Repeat(chunk, goroutines*writes))
+	if got := s.Sum256(); got != want {
+		t.Fatalf("SafeHasher = %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package keccak
+
+// Known answers for verifyASM: Keccak-256 of the empty string, which takes
+// the lone keccakF1600 path, of the bytes 0 to 135, which take two
+// xorAndPermute calls, and 32 bytes of TurboSHAKE128 of the empty string with
+// domain 0x1F (RFC 9861), which takes the 12-round keccakP12 path.
+var (
+	selfTestEmpty = [32]byte{
+		0xc5, 0xd2, 0x46, 0x01, 0x86, 0xf7, 0x23, 0x3c,
+		0x92, 0x7e, 0x7d, 0xb2, 0xdc, 0xc7, 0x03, 0xc0,
+		0xe5, 0x00, 0xb6, 0x53, 0xca, 0x82, 0x27, 0x3b,
+		0x7b, 0xfa, 0xd8, 0x04, 0x5d, 0x85, 0xa4, 0x70,
+	}
+	selfTestBlock = [32]byte{
+		0x7c, 0xe7, 0x59, 0xf1, 0xab, 0x7f, 0x9c, 0xe4,
+		0x37, 0x71, 0x99, 0x70, 0xc2, 0x6b, 0x0a, 0x66,
+		0xff, 0x11, 0xfe, 0x3e, 0x38, 0xe1, 0x7d, 0xf8,
+		0x9c, 0xf5, 0xd2, 0x9c, 0x7d, 0x7f, 0x80, 0x7e,
+	}
+	selfTestP12 = [32]byte{
+		0x1e, 0x41, 0x5f, 0x1c, 0x59, 0x83, 0xaf, 0xf2,
+		0x16, 0x92, 0x17, 0x27, 0x7d, 0x17, 0xbb, 0x53,
+		0x8c, 0xd9, 0x45, 0xa3, 0x97, 0xdd, 0xec, 0x54,
+		0x1f, 0x1c, 0xe4, 0x1a, 0xf2, 0xc1, 0xb7, 0x4c,
+	}
+)
+
+// verifyASM hashes the known answers through the accelerated permutation
+// and, if any digest is wrong, switches to the generic permutation and
+// reports false. Platform init calls it after selecting a kernel, so an
+// assembly or emulator fault on an unforeseen CPU costs speed instead of
+// silently corrupting every digest. The check takes four permutations.
+func verifyASM() bool {
+	if !useASM {
+		return true
+	}
+	var block [rate]byte
+	for i := range block {
+		block[i] = byte(i)
+	}
+	var state [200]byte
+	empty := sum256State(&state, nil)
+	state = [200]byte{}
+	full := sum256State(&state, block[:])
+	var p12 [32]byte
+	t := newTurboShake(turboShake128Rate, 0x1F)
+	t.squeeze(p12[:])
+	if empty == selfTestEmpty && full == selfTestBlock && p12 == selfTestP12 {
+		return true
+	}
+	useASM = false
+	return false
+}
@@ -0,0 +1,35 @@
+package keccak
+
+import "testing"
+
+func TestVerifyASM(t *testing.T) {
+	defer func(v bool) { useASM = v }(useASM)
+	defer SetPermutation(nil)
+
+	// On real hardware the selected permutation passes its own check.
+	if !verifyASM() {
+		t.Fatal("verifyASM failed for the permutation this build selected")
+	}
+
+	// A faulty accelerated path is caught and the generic one takes over.
+	useASM = true
+	SetPermutation(func(a *[200]byte) {
+		keccakF1600Generic(a)
+		a[7] ^= 0x40
+	})
+	if verifyASM() {
+		t.Fatal("verifyASM passed a faulty permutation")
+	}
+	if useASM {
+		t.Fatal("verifyASM left the faulty path enabled")
+	}
+	SetPermutation(nil)
+	if got, want := Sum256([]byte("abc")), refSum256([]byte("abc")); got != want {
+		t.Fatalf("after fallback Sum256 = %x, want %x", got, want)
+	}
+
+	// The 12-round known answer is what the generic Keccak-p[1600, 12] gives.
+	if got := [32]byte(TurboSHAKE128(nil, 0x1F, 32)); got != selfTestP12 {
+		t.Fatalf("generic TurboSHAKE128 = %x, want %x", got, selfTestP12)
+	}
+}
@@ -0,0 +1,94 @@
+package keccak
+
+import "hash"
+
+// SHA-3 uses the same permutation as Keccak but domain byte 0x06 (FIPS 202),
+// where legacy Keccak uses 0x01 and the SHAKE XOFs 0x1F.
+const sha3Domain = 0x06
+
+// SHA3 is a streaming FIPS 202 SHA-3 hash. Create one with NewSHA3_224,
+// NewSHA3_256, NewSHA3_384 or NewSHA3_512. A SHA3 must not be used
+// concurrently.
+type SHA3 struct {
+	s    keccakSponge
+	size int
+}
+
+var _ hash.Hash = (*SHA3)(nil)
+
+func newSHA3(size int) *SHA3 {
+	return &SHA3{s: keccakSponge{rate: 200 - 2*size, domain: sha3Domain}, size: size}
+}
+
+// NewSHA3_224 returns a SHA3-224 hash.
+func NewSHA3_224() *SHA3 { return newSHA3(28) }
+
+// NewSHA3_256 returns a SHA3-256 hash.
+func NewSHA3_256() *SHA3 { return newSHA3(32) }
+
+// NewSHA3_384 returns a SHA3-384 hash.
+func NewSHA3_384() *SHA3 { return newSHA3(48) }
+
+// NewSHA3_512 returns a SHA3-512 hash.
+func NewSHA3_512() *SHA3 { return newSHA3(64) }
+
+// Write absorbs p. It never returns an error.
+func (h *SHA3) Write(p []byte) (int, error) {
+	h.s.absorb(p)
+	return len(p), nil
+}
+
+// Sum appends the current digest to b and returns the resulting slice.
+// Does not modify the hash state.
+func (h *SHA3) Sum(b []byte) []byte {
+	s := h.s
+	n := len(b)
+	b = append(b, make([]byte, h.size)...)
+	s.squeeze(b[n:])
+	return b
+}
+
+// Reset resets the hash to its initial state.
+func (h *SHA3) Reset() { h.s.reset() }
+
+// Size returns the digest size in bytes.
+func (h *SHA3) Size() int { return h.size }
+
+// BlockSize returns the sponge rate in bytes.
+func (h *SHA3) BlockSize() int { return h.s.rate }
+
+// sha3Sum computes the SHA-3 digest of data into out, whose length selects
+// the variant.
+func sha3Sum(out, data []byte) {
+	s := keccakSponge{rate: 200 - 2*len(out), domain: sha3Domain}
+	s.absorb(data)
+	s.squeeze(out)
+}
+
+// SHA3Sum224 returns the SHA3-224 digest of data.
+func SHA3Sum224(data []byte) [28]byte {
+	var out [28]byte
+	sha3Sum(out[:], data)
+	return out
+}
+
+// SHA3Sum256 returns the SHA3-256 digest of data.
+func SHA3Sum256(data []byte) [32]byte {
+	var out [32]byte
+	sha3Sum(out[:], data)
+	return out
+}
+
+// SHA3Sum384 returns the SHA3-384 digest of data.
+func SHA3Sum384(data []byte) [48]byte {
+	var out [48]byte
+	sha3Sum(out[:], data)
+	return out
+}
+
+// SHA3Sum512 returns the SHA3-512 digest of data.
+func SHA3Sum512(data []byte) [64]byte {
+	var out [64]byte
+	sha3Sum(out[:], data)
+	return out
+}
@@ -0,0 +1,62 @@
+package keccak
+
+import (
+	"bytes"
+	"hash"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func FuzzSHA3(f *testing.F) {
+	f.Add([]byte(nil), uint8(0))
+	f.Add([]byte("abc"), uint8(1))
+	f.Add(make([]byte, 145), uint8(72))
+	f.Add(make([]byte, 3*rate+1), uint8(104))
+	f.Fuzz(func(t *testing.T, data []byte, split uint8) {
+		s224, s256, s384, s512 := SHA3Sum224(data), SHA3Sum256(data), SHA3Sum384(data), SHA3Sum512(data)
+		for _, tc := range []struct {
+			name string
+			sum  []byte
+			h    *SHA3
+			ref  func() hash.Hash
+			rate int
+		}{
+			{"SHA3-224", s224[:], NewSHA3_224(), sha3.New224, 144},
+			{"SHA3-256", s256[:], NewSHA3_256(), sha3.New256, 136},
+			{"SHA3-384", s384[:], NewSHA3_384(), sha3.New384, 104},
+			{"SHA3-512", s512[:], NewSHA3_512(), sha3.New512, 72},
+		} {
+			ref := tc.ref()
+			ref.Write(data)
+			want := ref.Sum(nil)
+			if !bytes.Equal(tc.sum, want) {
+				t.Fatalf("%s sum of %x = %x, want %x", tc.name, data, tc.sum, want)
+			}
+
+			cut := int(split) % (len(data) + 1)
+			tc.h.Write(data[:cut])
+			tc.h.Write(data[cut:])
+			if got := tc.h.Sum([]byte("p")); string(got[:1]) != "p" || !bytes.Equal(got[1:], want) {
+				t.Fatalf("%s streaming = %x, want p || %x", tc.name, got, want)
+			}
+			// Sum is non-destructive; Reset starts over.
+			if got := tc.h.Sum(nil); !bytes.Equal(got, want) {
+				t.Fatalf("%s second Sum = %x, want %x", tc.name, got, want)
+			}
+			if tc.h.Size() != ref.Size() || tc.h.BlockSize() != tc.rate || tc.rate != ref.BlockSize() {
+				t.Fatalf("%s Size, BlockSize = %d, %d", tc.name, tc.h.Size(), tc.h.BlockSize())
+			}
+			tc.h.Reset()
+			tc.h.Write(data)
+			if got := tc.h.Sum(nil); !bytes.Equal(got, want) {
+				t.Fatalf("%s after Reset = %x, want %x", tc.name, got, want)
+			}
+		}
+
+		// The domain byte is all that separates SHA3-256 from Keccak-256.
+		if s256 == Sum256(data) {
+			t.Fatalf("SHA3-256 and Keccak-256 agree on %x", data)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package keccak
+
+// LeftEncode encodes x as in SP 800-185 §2.3.1: the minimal big-endian byte
+// string of x, prefixed by its length in bytes. LeftEncode(0) is {1, 0}.
+func LeftEncode(x uint64) []byte {
+	return appendLeftEncode(make([]byte, 0, 9), x)
+}
+
+// appendLeftEncode appends LeftEncode(x) to dst without an intermediate slice.
+func appendLeftEncode(dst []byte, x uint64) []byte {
+	n := 1
+	for v := x >> 8; v != 0; v >>= 8 {
+		n++
+	}
+	dst = append(dst, byte(n))
+	for i := n - 1; i >= 0; i-- {
+		dst = append(dst, byte(x>>(8*i)))
+	}
+	return dst
+}
+
+// RightEncode encodes x as in SP 800-185 §2.3.1: the minimal big-endian byte
+// string of x, followed by its length in bytes. RightEncode(0) is {0, 1}.
+func RightEncode(x uint64) []byte {
+	var b [9]byte
+	n := 1
+	for v := x >> 8; v != 0; v >>= 8 {
+		n++
+	}
+	b[n] = byte(n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(x)
+		x >>= 8
+	}
+	return b[:n+1]
+}
+
+// Bytepad returns bytepad(data, w) as in SP 800-185 §2.3.3: LeftEncode(w)
+// followed by data, zero-padded to a multiple of w bytes.
+// Panics if w <= 0.
+func Bytepad(data []byte, w int) []byte {
+	if w <= 0 {
+		panic("keccak: Bytepad width must be positive")
+	}
+	z := append(LeftEncode(uint64(w)), data...)
+	if r := len(z) % w; r != 0 {
+		z = append(z, make([]byte, w-r)...)
+	}
+	return z
+}
+
+// newShake256 returns a SHAKE256 sponge.
+func newShake256() keccakSponge {
+	return keccakSponge{rate: 136, domain: 0x1F}
+}
+
+// newCShake256 returns a cSHAKE256 sponge with function name n and
+// customization string s (SP 800-185 §3). With both empty it is SHAKE256.
+func newCShake256(n, s []byte) keccakSponge {
+	if len(n) == 0 && len(s) == 0 {
+		return newShake256()
+	}
+	h := keccakSponge{rate: 136, domain: 0x04}
+	// bytepad(encode_string(N) || encode_string(S), 136)
+	h.absorb(LeftEncode(136))
+	writeEncodeString(&h, n)
+	writeEncodeString(&h, s)
+	h.absorbZeroPad()
+	return h
+}
+
+// writeEncodeString absorbs encode_string(s) = left_encode(len(s)*8) || s.
+func writeEncodeString(h *keccakSponge, s []byte) {
+	h.absorb(LeftEncode(uint64(len(s)) * 8))
+	h.absorb(s)
+}
+
+// TupleHash256 computes TupleHash256 (SP 800-185 §5) over tuple and returns
+// outputLen bytes. Each element is length-framed, so ("ab", "c") and
+// ("a", "bc") hash differently. customization may be nil.
+func TupleHash256(tuple [][]byte, customization []byte, outputLen int) []byte {
+	h := newCShake256([]byte("TupleHash"), customization)
+	for _, x := range tuple {
+		writeEncodeString(&h, x)
+	}
+	h.absorb(RightEncode(uint64(outputLen) * 8))
+	out := make([]byte, outputLen)
+	h.squeeze(out)
+	return out
+}
+
+// ParallelHash256 computes ParallelHash256 (SP 800-185 §6) of data split into
+// blockSize-byte blocks and returns outputLen bytes. Blocks are hashed
+// concurrently. customization may be nil. Panics if blockSize <= 0.
+func ParallelHash256(data []byte, blockSize int, customization []byte, outputLen int) []byte {
+	if blockSize <= 0 {
+		panic("keccak: ParallelHash256 block size must be positive")
+	}
+	n := (len(data) + blockSize - 1) / blockSize
+
+	// Each block is hashed with cSHAKE256(X_i, 512, "", ""), which is SHAKE256.
+	digests := make([]byte, n*64)
+	parallelFor(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			block := data[i*blockSize : min((i+1)*blockSize, len(data))]
+			leaf := newShake256()
+			leaf.absorb(block)
+			leaf.squeeze(digests[i*64 : (i+1)*64])
+		}
+	})
+
+	h := newCShake256([]byte("ParallelHash"), customization)
+	h.absorb(LeftEncode(uint64(blockSize)))
+	h.absorb(digests)
+	h.absorb(RightEncode(uint64(n)))
+	h.absorb(RightEncode(uint64(outputLen) * 8))
+	out := make([]byte, outputLen)
+	h.squeeze(out)
+	return out
+}
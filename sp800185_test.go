@@ -0,0 +1,157 @@
+package keccak
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestLeftRightEncode(t *testing.T) {
+	tests := []struct {
+		x           uint64
+		left, right string
+	}{
+		{0, "0100", "0001"},
+		{1, "0101", "0101"},
+		{255, "01ff", "ff01"},
+		{256, "020100", "010002"},
+		{1 << 63, "088000000000000000", "800000000000000008"},
+		{1<<64 - 1, "08ffffffffffffffff", "ffffffffffffffff08"},
+		{1 << 56, "080100000000000000", "010000000000000008"},
+		{1<<56 - 1, "07ffffffffffffff", "ffffffffffffff07"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(LeftEncode(tt.x)); got != tt.left {
+			t.Errorf("LeftEncode(%d) = %s, want %s", tt.x, got, tt.left)
+		}
+		if got := hex.EncodeToString(RightEncode(tt.x)); got != tt.right {
+			t.Errorf("RightEncode(%d) = %s, want %s", tt.x, got, tt.right)
+		}
+	}
+}
+
+func TestBytepad(t *testing.T) {
+	tests := []struct {
+		data string
+		w    int
+		want string
+	}{
+		{"", 1, "0101"},
+		{"", 4, "01040000"},
+		{"aabb", 4, "0104aabb"},
+		{"aabbcc", 4, "0104aabbcc000000"},
+		// The cSHAKE256 prefix for N = "", S = "Email Signature" (SP 800-185 §3.3).
+		{"0100" + "0178" + hex.EncodeToString([]byte("Email Signature")), 136,
+			"0188" + "0100" + "0178" + hex.EncodeToString([]byte("Email Signature")) + strings.Repeat("00", 136-2-2-2-15)},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.data)
+		got := Bytepad(data, tt.w)
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("Bytepad(%s, %d) = %x, want %s", tt.data, tt.w, got, tt.want)
+		}
+		if len(got)%tt.w != 0 {
+			t.Errorf("Bytepad(%s, %d) has length %d", tt.data, tt.w, len(got))
+		}
+	}
+}
+
+// NIST SP 800-185 TupleHash256 samples #4-#6.
+func TestTupleHash256(t *testing.T) {
+	x0, _ := hex.DecodeString("000102")
+	x1, _ := hex.DecodeString("101112131415")
+	x2, _ := hex.DecodeString("202122232425262728")
+	tests := []struct {
+		tuple [][]byte
+		s     string
+		want  string
+	}{
+		{
+			[][]byte{x0, x1}, "",
+			"cfb7058caca5e668f81a12a20a2195ce97a925f1dba3e7449a56f82201ec6073" +
+				"11ac2696b1ab5ea2352df1423bde7bd4bb78c9aed1a853c78672f9eb23bbe194",
+		},
+		{
+			[][]byte{x0, x1}, "My Tuple App",
+			"147c2191d5ed7efd98dbd96d7ab5a11692576f5fe2a5065f3e33de6bba9f3aa1" +
+				"c4e9a068a289c61c95aab30aee1e410b0b607de3620e24a4e3bf9852a1d4367e",
+		},
+		{
+			[][]byte{x0, x1, x2}, "My Tuple App",
+			"45000be63f9b6bfd89f54717670f69a9bc763591a4f05c50d68891a744bcc6e7" +
+				"d6d5b5e82c018da999ed35b0bb49c9678e526abd8e85c13ed254021db9e790ce",
+		},
+	}
+	for i, tt := range tests {
+		got := TupleHash256(tt.tuple, []byte(tt.s), 64)
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("sample %d: got %x, want %s", i+4, got, tt.want)
+		}
+	}
+}
+
+func TestTupleHash256Framing(t *testing.T) {
+	a := TupleHash256([][]byte{[]byte("ab"), []byte("c")}, nil, 32)
+	b := TupleHash256([][]byte{[]byte("a"), []byte("bc")}, nil, 32)
+	if bytes.Equal(a, b) {
+		t.Fatalf("TupleHash256 did not separate (ab, c) from (a, bc): %x", a)
+	}
+}
+
+// NIST SP 800-185 ParallelHash256 samples #4-#6.
+func TestParallelHash256(t *testing.T) {
+	seq := func(rows, width int) []byte {
+		var b []byte
+		for r := range rows {
+			for c := range width {
+				b = append(b, byte(r<<4|c))
+			}
+		}
+		return b
+	}
+	tests := []struct {
+		data      []byte
+		blockSize int
+		s         string
+		want      string
+	}{
+		{
+			seq(3, 8), 8, "",
+			"bc1ef124da34495e948ead207dd9842235da432d2bbc54b4c110e64c45110553" +
+				"1b7f2a3e0ce055c02805e7c2de1fb746af97a1dd01f43b824e31b87612410429",
+		},
+		{
+			seq(3, 8), 8, "Parallel Data",
+			"cdf15289b54f6212b4bc270528b49526006dd9b54e2b6add1ef6900dda3963bb" +
+				"33a72491f236969ca8afaea29c682d47a393c065b38e29fae651a2091c833110",
+		},
+		{
+			seq(6, 12), 12, "Parallel Data",
+			"69d0fcb764ea055dd09334bc6021cb7e4b61348dff375da262671cdec3effa8d" +
+				"1b4568a6cce16b1cad946ddde27f6ce2b8dee4cd1b24851ebf00eb90d43813e9",
+		},
+	}
+	for i, tt := range tests {
+		got := ParallelHash256(tt.data, tt.blockSize, []byte(tt.s), 64)
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("sample %d: got %x, want %s", i+4, got, tt.want)
+		}
+	}
+}
+
+func TestParallelHash256PartialBlock(t *testing.T) {
+	// The last partial block and an empty input must be framed consistently.
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	a := ParallelHash256(data, 64, nil, 32)
+	b := ParallelHash256(data[:999], 64, nil, 32)
+	if bytes.Equal(a, b) {
+		t.Fatal("dropping one byte of the last block did not change the digest")
+	}
+	if bytes.Equal(ParallelHash256(nil, 64, nil, 32), ParallelHash256(nil, 32, nil, 32)) {
+		t.Fatal("block size is not bound into the digest of an empty input")
+	}
+}
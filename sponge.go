@@ -0,0 +1,203 @@
+package keccak
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// keccakSponge is a Keccak sponge with a configurable rate, domain separation
+// byte and round count. Every construction other than the hot Keccak-256 path
+// is built on it: MultiHasher variants, SHAKE and cSHAKE for SP 800-185, and
+// TurboSHAKE and KangarooTwelve.
+type keccakSponge struct {
+	state     [200]byte
+	rate      int
+	domain    byte
+	rounds    int // 12 for Keccak-p[1600, 12]; anything else means Keccak-f[1600]
+	pos       int // absorb position, then read position once squeezing
+	squeezing bool
+}
+
+// permute applies the sponge's permutation to its state.
+func (s *keccakSponge) permute() {
+	if s.rounds == 12 {
+		keccakP12(&s.state)
+		return
+	}
+	keccakF1600(&s.state)
+}
+
+// absorb XORs p into the state, permuting after each full block.
+// Panics if called after squeeze.
+func (s *keccakSponge) absorb(p []byte) {
+	if s.squeezing {
+		panic("keccak: Write after Read")
+	}
+	for len(p) > 0 {
+		n := min(s.rate-s.pos, len(p))
+		subtle.XORBytes(s.state[s.pos:s.pos+n], s.state[s.pos:s.pos+n], p[:n])
+		s.pos += n
+		p = p[n:]
+		if s.pos == s.rate {
+			s.permute()
+			s.pos = 0
+		}
+	}
+}
+
+// absorbZeroPad absorbs zero bytes up to the next block boundary, as the
+// bytepad of SP 800-185 does.
+func (s *keccakSponge) absorbZeroPad() {
+	if s.pos != 0 {
+		s.permute()
+		s.pos = 0
+	}
+}
+
+// pad applies the domain byte and pad10*1, permutes, and switches the sponge
+// to squeezing. It is a no-op once squeezing.
+func (s *keccakSponge) pad() {
+	if s.squeezing {
+		return
+	}
+	s.padDomain(s.domain)
+}
+
+// padDomain is pad with domain in place of the sponge's own byte. The sponge
+// must still be absorbing.
+func (s *keccakSponge) padDomain(domain byte) {
+	s.state[s.pos] ^= domain
+	s.state[s.rate-1] ^= 0x80
+	s.permute()
+	s.squeezing = true
+	s.pos = 0
+}
+
+// squeeze reads len(out) bytes of output, padding first if needed.
+func (s *keccakSponge) squeeze(out []byte) {
+	s.pad()
+	for len(out) > 0 {
+		n := copy(out, s.state[s.pos:s.rate])
+		s.pos += n
+		out = out[n:]
+		if s.pos == s.rate {
+			s.permute()
+			s.pos = 0
+		}
+	}
+}
+
+// reset returns the sponge to its initial state, keeping its parameters.
+func (s *keccakSponge) reset() {
+	*s = keccakSponge{rate: s.rate, domain: s.domain, rounds: s.rounds}
+}
+
+// Sponge is a Keccak[c] sponge with a caller-chosen rate and domain
+// separation byte, for instances the package has no named constructor for.
+// Absorb with Write, then squeeze any amount of output with Read. For custom
+// constructions, Absorb, Finalize and Squeeze split those steps so the state
+// can be inspected between them with StateLanes. A Sponge must not be used
+// concurrently.
+type Sponge struct {
+	s keccakSponge
+}
+
+// NewKeccak returns a Sponge absorbing rateBytes bytes per Keccak-f[1600]
+// call, leaving a capacity of 200-rateBytes bytes, and finishing input with
+// domain followed by pad10*1. Legacy Keccak uses domain 0x01, SHA-3 0x06 and
+// SHAKE 0x1F. It returns an error unless 0 < rateBytes < 200 and domain is
+// in [0x01, 0x7F]: a zero domain would drop the first padding bit, and a
+// high bit could cancel the last one.
+func NewKeccak(rateBytes int, domain byte) (*Sponge, error) {
+	if rateBytes <= 0 || rateBytes >= 200 {
+		return nil, fmt.Errorf("keccak: rate %d bytes leaves no valid capacity, want 1 to 199", rateBytes)
+	}
+	if domain == 0 || domain >= 0x80 {
+		return nil, fmt.Errorf("keccak: domain byte %#02x outside [0x01, 0x7f]", domain)
+	}
+	return &Sponge{s: keccakSponge{rate: rateBytes, domain: domain}}, nil
+}
+
+// Write absorbs p. It never returns an error.
+// Panics if called after Read.
+func (s *Sponge) Write(p []byte) (int, error) {
+	s.s.absorb(p)
+	return len(p), nil
+}
+
+// Read squeezes len(out) bytes of output, padding the input on the first
+// call. It never returns an error.
+func (s *Sponge) Read(out []byte) (int, error) {
+	s.s.squeeze(out)
+	return len(out), nil
+}
+
+// Reset returns the sponge to its initial state, keeping rate and domain.
+func (s *Sponge) Reset() { s.s.reset() }
+
+// Rate returns the sponge rate in bytes.
+func (s *Sponge) Rate() int { return s.s.rate }
+
+// Absorb absorbs data, as Write does.
+// Panics if called after squeezing has begun.
+func (s *Sponge) Absorb(data []byte) { s.s.absorb(data) }
+
+// Finalize ends absorbing with domain, in place of the sponge's own domain
+// byte, followed by pad10*1, and permutes. Squeeze then reads from the
+// start of the resulting state, so StateLanes between Finalize and the first
+// Squeeze shows the block about to be read. Panics if domain is outside
+// [0x01, 0x7F] or if the sponge is already squeezing.
+func (s *Sponge) Finalize(domain byte) {
+	if domain == 0 || domain >= 0x80 {
+		panic("keccak: Finalize domain byte outside [0x01, 0x7f]")
+	}
+	if s.s.squeezing {
+		panic("keccak: Finalize after squeezing")
+	}
+	s.s.padDomain(domain)
+}
+
+// Squeeze reads len(out) bytes of output, as Read does, finalizing with the
+// sponge's own domain byte if Finalize has not been called.
+func (s *Sponge) Squeeze(out []byte) { s.s.squeeze(out) }
+
+// StateLanes returns the Keccak state as 25 lanes, lane x+5y at index
+// x+5y. Each lane is the little-endian reading of its 8 state bytes, as in
+// FIPS 202, whatever the byte order of the host. While absorbing, input
+// past the last full block is already XORed into the leading lanes.
+func (s *Sponge) StateLanes() [25]uint64 {
+	var lanes [25]uint64
+	for i := range lanes {
+		lanes[i] = binary.LittleEndian.Uint64(s.s.state[i*8:])
+	}
+	return lanes
+}
+
+// Reader returns a reader over the sponge's output stream, for use with
+// io.ReadFull, io.LimitReader or decoders that want an io.ByteReader. It
+// shares the sponge's squeeze position rather than finalizing again, so
+// reads through it and through Read continue one stream.
+func (s *Sponge) Reader() *SpongeReader { return &SpongeReader{s: s} }
+
+// SpongeReader reads the unbounded output of a Sponge; see Sponge.Reader.
+type SpongeReader struct {
+	s *Sponge
+}
+
+var (
+	_ io.Reader     = (*SpongeReader)(nil)
+	_ io.ByteReader = (*SpongeReader)(nil)
+)
+
+// Read fills p with the next len(p) bytes of output. It never returns an
+// error.
+func (r *SpongeReader) Read(p []byte) (int, error) { return r.s.Read(p) }
+
+// ReadByte returns the next byte of output. It never returns an error.
+func (r *SpongeReader) ReadByte() (byte, error) {
+	var b [1]byte
+	r.s.s.squeeze(b[:])
+	return b[0], nil
+}
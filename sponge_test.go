@@ -0,0 +1,289 @@
+package keccak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestKeccakSpongeVariants(t *testing.T) {
+	tests := []struct {
+		name   string
+		rate   int
+		domain byte
+		ref    func() hash.Hash
+	}{
+		{"Keccak256", 136, 0x01, sha3.NewLegacyKeccak256},
+		{"Keccak512", 72, 0x01, sha3.NewLegacyKeccak512},
+		{"SHA3-224", 144, 0x06, sha3.New224},
+		{"SHA3-256", 136, 0x06, sha3.New256},
+		{"SHA3-384", 104, 0x06, sha3.New384},
+		{"SHA3-512", 72, 0x06, sha3.New512},
+		{"SHAKE128", 168, 0x1F, func() hash.Hash { return sha3.NewShake128() }},
+		{"SHAKE256", 136, 0x1F, func() hash.Hash { return sha3.NewShake256() }},
+		{"cSHAKE256", 136, 0x04, nil},
+	}
+	for _, tt := range tests {
+		for _, n := range []int{0, 1, 71, 72, 135, 136, 137, 168, 500} {
+			t.Run(fmt.Sprintf("%s/%d", tt.name, n), func(t *testing.T) {
+				data := make([]byte, n)
+				for i := range data {
+					data[i] = byte(i*13 + 7)
+				}
+				var s keccakSponge
+				var ref hash.Hash
+				if tt.ref == nil {
+					s = newCShake256([]byte("N"), []byte("custom"))
+					ref = sha3.NewCShake256([]byte("N"), []byte("custom"))
+				} else {
+					s = keccakSponge{rate: tt.rate, domain: tt.domain}
+					ref = tt.ref()
+				}
+				s.absorb(data[:n/2])
+				s.absorb(data[n/2:])
+				ref.Write(data)
+
+				// XOFs are checked across several output blocks.
+				outLen := ref.Size()
+				if r, ok := ref.(sha3.ShakeHash); ok {
+					outLen = 3*tt.rate + 5
+					want := make([]byte, outLen)
+					r.Read(want)
+					got := make([]byte, outLen)
+					s.squeeze(got[:10])
+					s.squeeze(got[10:])
+					if string(got) != string(want) {
+						t.Fatalf("got %x\nwant %x", got, want)
+					}
+					return
+				}
+				got := make([]byte, outLen)
+				s.squeeze(got)
+				if want := ref.Sum(nil); string(got) != string(want) {
+					t.Fatalf("got %x, want %x", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestKeccakSpongeReset(t *testing.T) {
+	s := keccakSponge{rate: 136, domain: 0x01}
+	s.absorb([]byte("discarded"))
+	var out [32]byte
+	s.squeeze(out[:])
+	s.reset()
+	s.absorb([]byte("abc"))
+	s.squeeze(out[:])
+	if want := Sum256([]byte("abc")); out != want {
+		t.Fatalf("after reset got %x, want %x", out, want)
+	}
+}
+
+func TestNewKeccak(t *testing.T) {
+	for _, tc := range []struct {
+		rate   int
+		domain byte
+		ref    func() hash.Hash
+	}{
+		{72, 0x01, sha3.NewLegacyKeccak512},
+		{104, 0x06, sha3.New384},
+		{136, 0x01, sha3.NewLegacyKeccak256},
+		{144, 0x06, sha3.New224},
+		{168, 0x1F, func() hash.Hash { return sha3.NewShake128() }},
+	} {
+		s, err := NewKeccak(tc.rate, tc.domain)
+		if err != nil {
+			t.Fatalf("NewKeccak(%d, %#x): %v", tc.rate, tc.domain, err)
+		}
+		data := make([]byte, 2*tc.rate+3)
+		ref := tc.ref()
+		s.Write(data)
+		ref.Write(data)
+		want := ref.Sum(nil)
+		got := make([]byte, len(want))
+		s.Read(got)
+		if string(got) != string(want) || s.Rate() != tc.rate {
+			t.Errorf("rate %d: got %x, want %x", tc.rate, got, want)
+		}
+		s.Reset()
+		s.Write(data)
+		s.Read(got)
+		if string(got) != string(want) {
+			t.Errorf("rate %d after Reset: got %x, want %x", tc.rate, got, want)
+		}
+	}
+
+	for _, tc := range []struct {
+		rate   int
+		domain byte
+	}{
+		{0, 0x01}, {200, 0x01}, {-8, 0x01}, {1000, 0x01}, {136, 0x00}, {136, 0x80}, {136, 0xFF},
+	} {
+		if s, err := NewKeccak(tc.rate, tc.domain); err == nil || s != nil {
+			t.Errorf("NewKeccak(%d, %#x) = %v, %v; want an error", tc.rate, tc.domain, s, err)
+		}
+	}
+}
+
+// FuzzShake256 checks the multi-block squeeze of the SHAKE256 sponge behind
+// Rand and NewKeccak(136, 0x1F) against x/crypto. Input is written in chunks
+// of up to step bytes and output read in chunks of up to step bytes, so
+// absorb and squeeze positions land on and across every rate boundary.
+func FuzzShake256(f *testing.F) {
+	for _, outLen := range []uint16{0, 1, 32, 135, 136, 137, 272, 273, 1000} {
+		f.Add([]byte("abc"), outLen, uint8(0))
+		f.Add(make([]byte, rate), outLen, uint8(rate-1))
+	}
+	f.Add(make([]byte, 3*rate+1), uint16(2*rate), uint8(7))
+	f.Add(make([]byte, rate-1), uint16(rate+1), uint8(255))
+
+	f.Fuzz(func(t *testing.T, data []byte, outLen uint16, step uint8) {
+		n := int(outLen) % 4096
+		chunk := int(step) + 1
+
+		ref := sha3.NewShake256()
+		ref.Write(data)
+		want := make([]byte, n)
+		ref.Read(want)
+
+		s := newShake256()
+		for p := data; len(p) > 0; {
+			m := min(chunk, len(p))
+			s.absorb(p[:m])
+			p = p[m:]
+		}
+		got := make([]byte, n)
+		for i, off := 0, 0; off < n; i++ {
+			// Vary the read size so successive reads start at different
+			// offsets within a block; an empty read must not advance.
+			m := min((chunk*(i%3))%(2*rate), n-off)
+			s.squeeze(got[off : off+m])
+			off += m
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("len=%d out=%d step=%d\ngot:  %x\nwant: %x", len(data), n, chunk, got, want)
+		}
+
+		sp, err := NewKeccak(rate, 0x1F)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sp.Write(data)
+		clear(got)
+		sp.Read(got)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("Sponge len=%d out=%d\ngot:  %x\nwant: %x", len(data), n, got, want)
+		}
+	})
+}
+
+func TestSpongeReader(t *testing.T) {
+	newShake := func() *Sponge {
+		s, err := NewKeccak(rate, 0x1F)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Write([]byte("reader"))
+		return s
+	}
+	want := make([]byte, 3*rate+10)
+	newShake().Read(want)
+
+	// io.ReadFull, ReadByte and Sponge.Read all draw on one stream.
+	s := newShake()
+	r := s.Reader()
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(r, got[:rate+1]); err != nil {
+		t.Fatal(err)
+	}
+	for i := rate + 1; i < 2*rate; i++ {
+		c, err := r.ReadByte()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[i] = c
+	}
+	s.Read(got[2*rate : 2*rate+5])
+	if _, err := io.ReadFull(io.LimitReader(r, 1000), got[2*rate+5:]); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got  %x\nwant %x", got, want)
+	}
+
+	// Uvarint decoding consumes exactly the bytes it needs.
+	r = newShake().Reader()
+	v, err := binary.ReadUvarint(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantV, n := binary.Uvarint(want); n <= 0 || v != wantV {
+		t.Fatalf("ReadUvarint = %d, want %d", v, wantV)
+	}
+}
+
+func TestSpongeAbsorbFinalizeSqueeze(t *testing.T) {
+	for _, n := range []int{0, 1, rate - 1, rate, 2*rate + 7} {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i*5 + 2)
+		}
+		// Built with the SHA-3 domain, finalized as Keccak-256.
+		s, err := NewKeccak(rate, 0x06)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Absorb(data[:n/2])
+		s.Absorb(data[n/2:])
+		s.Finalize(0x01)
+
+		// The first lanes of the finalized state are the digest.
+		want := Sum256(data)
+		lanes := s.StateLanes()
+		for i := range 4 {
+			if got := binary.LittleEndian.Uint64(want[i*8:]); lanes[i] != got {
+				t.Fatalf("len=%d: lane %d = %#x, want %#x", n, i, lanes[i], got)
+			}
+		}
+		var got [32]byte
+		s.Squeeze(got[:16])
+		s.Squeeze(got[16:])
+		if got != want {
+			t.Errorf("len=%d: Absorb+Finalize+Squeeze = %x, want Sum256 %x", n, got, want)
+		}
+
+		// Reset keeps the constructed domain, not the one Finalize used.
+		s.Reset()
+		s.Absorb(data)
+		s.Squeeze(got[:])
+		if want := sha3.Sum256(data); got != want {
+			t.Errorf("len=%d: after Reset = %x, want SHA3-256 %x", n, got, want)
+		}
+	}
+}
+
+func TestSpongeFinalizePanics(t *testing.T) {
+	for name, f := range map[string]func(s *Sponge){
+		"domain 0":      func(s *Sponge) { s.Finalize(0) },
+		"domain 0x80":   func(s *Sponge) { s.Finalize(0x80) },
+		"twice":         func(s *Sponge) { s.Finalize(0x01); s.Finalize(0x01) },
+		"after squeeze": func(s *Sponge) { s.Squeeze(make([]byte, 1)); s.Finalize(0x01) },
+		"absorb after":  func(s *Sponge) { s.Finalize(0x01); s.Absorb([]byte("x")) },
+	} {
+		s, _ := NewKeccak(rate, 0x01)
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: did not panic", name)
+				}
+			}()
+			f(s)
+		}()
+	}
+}
@@ -0,0 +1,39 @@
+package keccak
+
+const (
+	turboShake128Rate = 168
+	turboShake256Rate = 136
+)
+
+// TurboSHAKE128 computes TurboSHAKE128 (RFC 9861) of data with domain
+// separation byte domain and returns outputLen bytes. It uses 12 rounds of
+// Keccak-p[1600] instead of the 24 of SHAKE128.
+// Panics if domain is outside [0x01, 0x7F].
+func TurboSHAKE128(data []byte, domain byte, outputLen int) []byte {
+	return turboShakeSum(turboShake128Rate, data, domain, outputLen)
+}
+
+// TurboSHAKE256 computes TurboSHAKE256 (RFC 9861) of data with domain
+// separation byte domain and returns outputLen bytes. It uses 12 rounds of
+// Keccak-p[1600] instead of the 24 of SHAKE256.
+// Panics if domain is outside [0x01, 0x7F].
+func TurboSHAKE256(data []byte, domain byte, outputLen int) []byte {
+	return turboShakeSum(turboShake256Rate, data, domain, outputLen)
+}
+
+func turboShakeSum(rate int, data []byte, domain byte, outputLen int) []byte {
+	if domain < 0x01 || domain > 0x7F {
+		panic("keccak: TurboSHAKE domain byte out of range")
+	}
+	t := newTurboShake(rate, domain)
+	t.absorb(data)
+	out := make([]byte, outputLen)
+	t.squeeze(out)
+	return out
+}
+
+// newTurboShake returns a sponge over Keccak-p[1600, 12], as used by
+// TurboSHAKE (RFC 9861).
+func newTurboShake(rate int, domain byte) keccakSponge {
+	return keccakSponge{rate: rate, domain: domain, rounds: 12}
+}
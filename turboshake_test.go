@@ -0,0 +1,71 @@
+package keccak
+
+import (
+	"bytes"
+	"testing"
+)
+
+// RFC 9861 §5 TurboSHAKE test vectors.
+func TestTurboSHAKEVectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		f       func([]byte, byte, int) []byte
+		m       []byte
+		domain  byte
+		outLen  int
+		lastLen int // compare only the last lastLen bytes when > 0
+		want    string
+	}{
+		{"128/M=empty,L=32", TurboSHAKE128, nil, 0x1F, 32, 0,
+			"1E415F1C5983AFF2169217277D17BB538CD945A397DDEC541F1CE41AF2C1B74C"},
+		{"128/M=empty,L=64", TurboSHAKE128, nil, 0x1F, 64, 0,
+			"1E415F1C5983AFF2169217277D17BB538CD945A397DDEC541F1CE41AF2C1B74C" +
+				"3E8CCAE2A4DAE56C84A04C2385C03C15E8193BDF58737363321691C05462C8DF"},
+		{"128/M=empty,L=10032", TurboSHAKE128, nil, 0x1F, 10032, 32,
+			"A3B9B0385900CE761F22AED548E754DA10A5242D62E8C658E3F3A923A7555607"},
+		{"128/M=ptn(1)", TurboSHAKE128, ptn(1), 0x1F, 32, 0,
+			"55CEDD6F60AF7BB29A4042AE832EF3F58DB7299F893EBB9247247D856958DAA9"},
+		{"128/M=ptn(17)", TurboSHAKE128, ptn(17), 0x1F, 32, 0,
+			"9C97D036A3BAC819DB70EDE0CA554EC6E4C2A1A4FFBFD9EC269CA6A111161233"},
+		{"128/M=FFFFFF,D=01", TurboSHAKE128, []byte{0xFF, 0xFF, 0xFF}, 0x01, 32, 0,
+			"BF323F940494E88EE1C540FE660BE8A0C93F43D15EC006998462FA994EED5DAB"},
+		{"128/M=FF,D=06", TurboSHAKE128, []byte{0xFF}, 0x06, 32, 0,
+			"8EC9C66465ED0D4A6C35D13506718D687A25CB05C74CCA1E42501ABD83874A67"},
+		{"128/M=FFx7,D=0B", TurboSHAKE128, bytes.Repeat([]byte{0xFF}, 7), 0x0B, 32, 0,
+			"8DEEAA1AEC47CCEE569F659C21DFA8E112DB3CEE37B18178B2ACD805B799CC37"},
+		{"128/M=FF,D=30", TurboSHAKE128, []byte{0xFF}, 0x30, 32, 0,
+			"553122E2135E363C3292BED2C6421FA232BAB03DAA07C7D6636603286506325B"},
+		{"128/M=FFFFFF,D=7F", TurboSHAKE128, []byte{0xFF, 0xFF, 0xFF}, 0x7F, 32, 0,
+			"16274CC656D44CEFD422395D0F9053BDA6D28E122ABA15C765E5AD0E6EAF26F9"},
+		{"256/M=empty", TurboSHAKE256, nil, 0x1F, 32, 0,
+			"367A329DAFEA871C7802EC67F905AE13C57695DC2C6663C61035F59A18F8E7DB"},
+		{"256/M=ptn(1)", TurboSHAKE256, ptn(1), 0x1F, 64, 0,
+			"3E1712F928F8EAF1054632B2AA0A246ED8B0C378728F60BC970410155C28820E" +
+				"90CC90D8A3006AA2372C5C5EA176B0682BF22BAE7467AC94F74D43D39B0482E2"},
+		{"256/M=FFFFFF,D=01", TurboSHAKE256, []byte{0xFF, 0xFF, 0xFF}, 0x01, 64, 0,
+			"D21C6FBBF587FA2282F29AEA620175FB0257413AF78A0B1B2A87419CE031D933" +
+				"AE7A4D383327A8A17641A34F8A1D1003AD7DA6B72DBA84BB62FEF28F62F12424"},
+	}
+	for _, tt := range tests {
+		got := tt.f(tt.m, tt.domain, tt.outLen)
+		if tt.lastLen > 0 {
+			got = got[len(got)-tt.lastLen:]
+		}
+		if want := unhex(tt.want); !bytes.Equal(got, want) {
+			t.Errorf("%s: got %X, want %X", tt.name, got, want)
+		}
+	}
+}
+
+func TestTurboSHAKEDomainRange(t *testing.T) {
+	for _, d := range []byte{0x00, 0x80, 0xFF} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("TurboSHAKE128 with domain %#x did not panic", d)
+				}
+			}()
+			TurboSHAKE128(nil, d, 32)
+		}()
+	}
+}